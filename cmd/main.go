@@ -1,7 +1,24 @@
 package main
 
+import (
+	"fmt"
+	"os"
+
+	iDI "github.com/puipuipartpicker/kbpartpicker/api/internal/di"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/config"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
+)
+
 func main() {
-	// ...
-	// GetServer()
-	// ...
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		if err := config.Print(os.Stdout, new(config.Config)); err != nil {
+			fmt.Fprintf(os.Stderr, "%+v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	iDI.GetServer()
+	di.Run()
 }
@@ -3,9 +3,25 @@ module github.com/puipuipartpicker/kbpartpicker/api
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v0.4.1
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/gofiber/fiber/v2 v2.22.0
+	github.com/golang-jwt/jwt/v4 v4.5.2
+	github.com/oklog/ulid/v2 v2.0.2
+	github.com/prometheus/client_golang v1.11.0
+	github.com/valyala/fasthttp v1.31.0
 	go.mongodb.org/mongo-driver v1.8.0
+	go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo v0.27.0
+	go.opentelemetry.io/otel v1.2.0
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.2.0
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.2.0
+	go.opentelemetry.io/otel/sdk v1.2.0
+	go.opentelemetry.io/otel/trace v1.2.0
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
 	go.uber.org/zap v1.19.1
+	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
+	golang.org/x/text v0.3.6
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
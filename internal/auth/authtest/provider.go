@@ -0,0 +1,146 @@
+// Package authtest provides a fake OAuth provider and in-memory repositories to test
+// authentication without network access or a database.
+package authtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+)
+
+// Provider is a local OAuth provider speaking the Google user info format. Its consent page
+// immediately redirects back with a code for the user set with SetUser.
+type Provider struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	user   auth.ExternalUser
+	codes  map[string]bool
+	tokens map[string]auth.ExternalUser
+	next   int
+}
+
+// NewProvider starts a fake provider; Close stops it.
+func NewProvider(user auth.ExternalUser) *Provider {
+	p := &Provider{user: user, codes: map[string]bool{}, tokens: map[string]auth.ExternalUser{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", p.authorize)
+	mux.HandleFunc("/token", p.token)
+	mux.HandleFunc("/userinfo", p.userInfo)
+
+	p.server = httptest.NewServer(mux)
+
+	return p
+}
+
+// Close stops the provider.
+func (p *Provider) Close() {
+	p.server.Close()
+}
+
+// SetUser sets the user logging in next.
+func (p *Provider) SetUser(user auth.ExternalUser) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.user = user
+}
+
+// Config returns an auth.Provider named name using the fake provider.
+func (p *Provider) Config(name, redirectURL string) *auth.Provider {
+	c := auth.Google("client-id", "client-secret", redirectURL)
+	c.Name = name
+	c.AuthURL = p.server.URL + "/authorize"
+	c.TokenURL = p.server.URL + "/token"
+	c.UserInfoURL = p.server.URL + "/userinfo"
+
+	return c
+}
+
+// Code returns a valid authorization code for the current user, as if the user consented.
+func (p *Provider) Code() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.next++
+	code := "code-" + strconv.Itoa(p.next)
+	p.codes[code] = true
+
+	return code
+}
+
+func (p *Provider) authorize(w http.ResponseWriter, r *http.Request) {
+	redirect, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	q := redirect.Query()
+	q.Set("code", p.Code())
+	q.Set("state", r.URL.Query().Get("state"))
+	redirect.RawQuery = q.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	code := r.PostForm.Get("code")
+	if !p.codes[code] {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	// codes are single use.
+	delete(p.codes, code)
+
+	token := "token-" + code
+	p.tokens[token] = p.user
+
+	writeJSON(w, map[string]interface{}{"access_token": token, "token_type": "Bearer", "expires_in": 3600})
+}
+
+func (p *Provider) userInfo(w http.ResponseWriter, r *http.Request) {
+	const prefix = "Bearer "
+
+	header := r.Header.Get("Authorization")
+	if len(header) <= len(prefix) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	p.mu.Lock()
+	u, ok := p.tokens[header[len(prefix):]]
+	p.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"sub":            u.Subject,
+		"email":          u.Email,
+		"email_verified": u.EmailVerified,
+		"name":           u.Name,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,163 @@
+package authtest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+)
+
+// Users is an in-memory auth.UserRepository.
+type Users struct {
+	mu    sync.Mutex
+	users map[string]auth.User
+}
+
+// NewUsers returns an empty repository.
+func NewUsers() *Users {
+	return &Users{users: map[string]auth.User{}}
+}
+
+// Create implements auth.UserRepository.
+func (r *Users) Create(_ context.Context, u *auth.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, o := range r.users {
+		if u.Email != "" && o.Email == u.Email {
+			return auth.ErrEmailTaken
+		}
+	}
+
+	r.users[u.ID] = copyUser(u)
+
+	return nil
+}
+
+// FindByID implements auth.UserRepository.
+func (r *Users) FindByID(_ context.Context, id string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool { return u.ID == id })
+}
+
+// FindByEmail implements auth.UserRepository.
+func (r *Users) FindByEmail(_ context.Context, email string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool { return email != "" && u.Email == email })
+}
+
+// FindByIdentity implements auth.UserRepository.
+func (r *Users) FindByIdentity(_ context.Context, provider, subject string) (*auth.User, error) {
+	return r.find(func(u *auth.User) bool {
+		for _, i := range u.Identities {
+			if i.Provider == provider && i.Subject == subject {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// AddIdentity implements auth.UserRepository.
+func (r *Users) AddIdentity(_ context.Context, userID string, identity auth.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[userID]
+	if !ok {
+		return auth.ErrUserNotFound
+	}
+
+	u.Identities = append(append([]auth.Identity{}, u.Identities...), identity)
+	r.users[userID] = u
+
+	return nil
+}
+
+func (r *Users) find(match func(u *auth.User) bool) (*auth.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if match(&u) {
+			c := copyUser(&u)
+			return &c, nil
+		}
+	}
+
+	return nil, auth.ErrUserNotFound
+}
+
+func copyUser(u *auth.User) auth.User {
+	c := *u
+	c.Identities = append([]auth.Identity{}, u.Identities...)
+
+	return c
+}
+
+// RefreshTokens is an in-memory auth.RefreshTokenRepository.
+type RefreshTokens struct {
+	mu     sync.Mutex
+	tokens map[string]auth.RefreshToken
+}
+
+// NewRefreshTokens returns an empty repository.
+func NewRefreshTokens() *RefreshTokens {
+	return &RefreshTokens{tokens: map[string]auth.RefreshToken{}}
+}
+
+// Create implements auth.RefreshTokenRepository.
+func (r *RefreshTokens) Create(_ context.Context, t *auth.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[t.ID] = *t
+
+	return nil
+}
+
+// FindByHash implements auth.RefreshTokenRepository.
+func (r *RefreshTokens) FindByHash(_ context.Context, hash string) (*auth.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			c := t
+			return &c, nil
+		}
+	}
+
+	return nil, auth.ErrTokenNotFound
+}
+
+// Revoke implements auth.RefreshTokenRepository.
+func (r *RefreshTokens) Revoke(_ context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return auth.ErrTokenRevoked
+	}
+
+	t.RevokedAt = &at
+	r.tokens[id] = t
+
+	return nil
+}
+
+// RevokeFamily implements auth.RefreshTokenRepository.
+func (r *RefreshTokens) RevokeFamily(_ context.Context, family string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.tokens {
+		if t.Family == family && t.RevokedAt == nil {
+			t.RevokedAt = &at
+			r.tokens[id] = t
+		}
+	}
+
+	return nil
+}
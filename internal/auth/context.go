@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"context"
+)
+
+type ctxKey int
+
+const principalCtxKey ctxKey = iota
+
+// Principal is the authenticated user of a request.
+type Principal struct {
+	UserID string
+	Email  string
+}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey, p)
+}
+
+// PrincipalFromContext returns the authenticated user stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+)
+
+const (
+	// authErrorLocal holds why Authenticate rejected the access token of a request.
+	authErrorLocal = "authError"
+
+	// stateCookie holds the OAuth state between the redirect and the callback.
+	stateCookie = "oauth_state"
+	stateTTL    = 10 * time.Minute
+)
+
+// Handler serves the authentication endpoints.
+type Handler struct {
+	service *Service
+
+	// secureCookies sets the Secure attribute of cookies; disabled for local development over http.
+	secureCookies bool
+}
+
+// NewHandler returns the handler of service.
+func NewHandler(service *Service, secureCookies bool) *Handler {
+	return &Handler{service: service, secureCookies: secureCookies}
+}
+
+// Install registers the endpoints under r, e.g. the v1 group, which must use Authenticate.
+func (h *Handler) Install(r fiber.Router) {
+	a := r.Group("/auth")
+	a.Post("/register", h.register)
+	a.Post("/login", h.login)
+	a.Post("/refresh", h.refresh)
+	a.Post("/logout", h.logout)
+	a.Get("/me", RequireUser, h.me)
+	a.Get("/oauth/:provider", h.oauthRedirect)
+	a.Get("/oauth/:provider/callback", h.oauthCallback)
+}
+
+// Authenticate is a fiber middleware putting the user of a bearer access token into the
+// user context, for PrincipalFromContext and the request logs. Requests without a valid
+// token pass through anonymously, so that e.g. an expired token does not prevent a refresh;
+// RequireUser then reports why the token was rejected.
+func (h *Handler) Authenticate(c *fiber.Ctx) error {
+	header := c.Get(fiber.HeaderAuthorization)
+	if header == "" {
+		return c.Next()
+	}
+
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		c.Locals(authErrorLocal, appErr.Unauthorized("invalid authorization header"))
+		return c.Next()
+	}
+
+	claims, err := h.service.Tokens().Parse(header[len(prefix):])
+	if err != nil {
+		c.Locals(authErrorLocal, appErr.Wrap(appErr.ErrCodeUnauthorized, err, "invalid access token"))
+		return c.Next()
+	}
+
+	ctx := WithPrincipal(c.UserContext(), &Principal{UserID: claims.Subject, Email: claims.Email})
+	c.SetUserContext(logging.WithUserID(ctx, claims.Subject))
+
+	return c.Next()
+}
+
+// RequireUser is a fiber middleware rejecting anonymous requests. It must follow Authenticate.
+func RequireUser(c *fiber.Ctx) error {
+	if _, ok := PrincipalFromContext(c.UserContext()); ok {
+		return c.Next()
+	}
+
+	if err, ok := c.Locals(authErrorLocal).(error); ok {
+		return err
+	}
+
+	return appErr.Unauthorized("")
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type registerResponse struct {
+	User   *User      `json:"user"`
+	Tokens *TokenPair `json:"tokens"`
+}
+
+func (h *Handler) register(c *fiber.Ctx) error {
+	var req credentials
+	if err := c.BodyParser(&req); err != nil {
+		return appErr.Validation("invalid body")
+	}
+
+	u, pair, err := h.service.Register(c.UserContext(), req.Email, req.Password, req.Name)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(http.StatusCreated).JSON(&registerResponse{User: u, Tokens: pair})
+}
+
+func (h *Handler) login(c *fiber.Ctx) error {
+	var req credentials
+	if err := c.BodyParser(&req); err != nil {
+		return appErr.Validation("invalid body")
+	}
+
+	pair, err := h.service.Login(c.UserContext(), req.Email, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(pair)
+}
+
+func (h *Handler) refresh(c *fiber.Ctx) error {
+	var req refreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return appErr.Validation("", appErr.InvalidParam{Name: "refreshToken", Reason: "is required"})
+	}
+
+	pair, err := h.service.Refresh(c.UserContext(), req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(pair)
+}
+
+func (h *Handler) logout(c *fiber.Ctx) error {
+	var req refreshRequest
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return appErr.Validation("", appErr.InvalidParam{Name: "refreshToken", Reason: "is required"})
+	}
+
+	if err := h.service.Logout(c.UserContext(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	return c.SendStatus(http.StatusNoContent)
+}
+
+func (h *Handler) me(c *fiber.Ctx) error {
+	p, _ := PrincipalFromContext(c.UserContext())
+
+	u, err := h.service.User(c.UserContext(), p.UserID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(u)
+}
+
+func (h *Handler) oauthRedirect(c *fiber.Ctx) error {
+	p, ok := h.service.Provider(c.Params("provider"))
+	if !ok {
+		return appErr.NotFound("")
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	state := base64.RawURLEncoding.EncodeToString(b)
+
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     c.Path() + "/callback",
+		Expires:  time.Now().Add(stateTTL),
+		Secure:   h.secureCookies,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	return c.Redirect(p.AuthCodeURL(state), http.StatusFound)
+}
+
+func (h *Handler) oauthCallback(c *fiber.Ctx) error {
+	p, ok := h.service.Provider(c.Params("provider"))
+	if !ok {
+		return appErr.NotFound("")
+	}
+
+	// the state is single use.
+	state := c.Cookies(stateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     stateCookie,
+		Path:     c.Path(),
+		Expires:  time.Unix(0, 0),
+		Secure:   h.secureCookies,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		return appErr.Unauthorized("invalid oauth state")
+	}
+
+	if e := c.Query("error"); e != "" {
+		return appErr.Unauthorized("oauth login denied: " + e)
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		return appErr.Validation("", appErr.InvalidParam{Name: "code", Reason: "is required"})
+	}
+
+	pair, err := h.service.LoginWithProvider(c.UserContext(), p, code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(pair)
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/tracing"
+)
+
+// OAuth provider names.
+const (
+	ProviderDiscord = "discord"
+	ProviderGoogle  = "google"
+)
+
+// maxProviderResponse bounds the responses read from providers.
+const maxProviderResponse = 1 << 20
+
+var errNoAccessToken = errors.New("provider returned no access token")
+
+// ExternalUser is the account of a user at an OAuth provider.
+type ExternalUser struct {
+	Subject string
+	Email   string
+	Name    string
+
+	// EmailVerified tells whether the provider verified the email, so that it can be
+	// used to link the account to an existing user.
+	EmailVerified bool
+}
+
+// Provider is an OAuth2 provider supporting the authorization code flow.
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+
+	// decodeUser decodes the user info response.
+	decodeUser func(body []byte) (*ExternalUser, error)
+
+	client *http.Client
+}
+
+// Discord returns the Discord provider.
+func Discord(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:         ProviderDiscord,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"identify", "email"},
+		AuthURL:      "https://discord.com/oauth2/authorize",
+		TokenURL:     "https://discord.com/api/oauth2/token",
+		UserInfoURL:  "https://discord.com/api/users/@me",
+		decodeUser:   decodeDiscordUser,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Google returns the Google provider.
+func Google(clientID, clientSecret, redirectURL string) *Provider {
+	return &Provider{
+		Name:         ProviderGoogle,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+		decodeUser:   decodeGoogleUser,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AuthCodeURL returns the URL of the consent page, which redirects back with a code and state.
+func (p *Provider) AuthCodeURL(state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {p.RedirectURL},
+		"scope":         {strings.Join(p.Scopes, " ")},
+		"state":         {state},
+	}
+
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+
+	return p.AuthURL + sep + q.Encode()
+}
+
+// Exchange exchanges an authorization code for the account of the user at the provider.
+func (p *Provider) Exchange(ctx context.Context, code string) (*ExternalUser, error) {
+	token, err := p.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build user info request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	body, err := p.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s user: %w", p.Name, err)
+	}
+
+	u, err := p.decodeUser(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s user: %w", p.Name, err)
+	}
+
+	if u.Subject == "" {
+		return nil, fmt.Errorf("%s returned a user without id", p.Name)
+	}
+
+	return u, nil
+}
+
+func (p *Provider) exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := p.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange %s code: %w", p.Name, err)
+	}
+
+	var res struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("failed to decode %s token: %w", p.Name, err)
+	}
+
+	if res.AccessToken == "" {
+		return "", errNoAccessToken
+	}
+
+	return res.AccessToken, nil
+}
+
+func (p *Provider) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	tracing.Inject(req.Context(), req.Header)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := retry.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxProviderResponse))
+}
+
+func decodeDiscordUser(body []byte) (*ExternalUser, error) {
+	var u struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Email      string `json:"email"`
+		Verified   bool   `json:"verified"`
+	}
+
+	if err := json.Unmarshal(body, &u); err != nil {
+		return nil, err
+	}
+
+	name := u.GlobalName
+	if name == "" {
+		name = u.Username
+	}
+
+	return &ExternalUser{Subject: u.ID, Email: u.Email, Name: name, EmailVerified: u.Verified}, nil
+}
+
+func decodeGoogleUser(body []byte) (*ExternalUser, error) {
+	var u struct {
+		Sub           string `json:"sub"`
+		Name          string `json:"name"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+
+	if err := json.Unmarshal(body, &u); err != nil {
+		return nil, err
+	}
+
+	return &ExternalUser{Subject: u.Sub, Email: u.Email, Name: u.Name, EmailVerified: u.EmailVerified}, nil
+}
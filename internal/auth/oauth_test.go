@@ -0,0 +1,164 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth/authtest"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+)
+
+const testProvider = "google"
+
+func newProvider(t *testing.T, user auth.ExternalUser) (*authtest.Provider, *auth.Provider) {
+	t.Helper()
+
+	fake := authtest.NewProvider(user)
+	t.Cleanup(fake.Close)
+
+	return fake, fake.Config(testProvider, "http://localhost/v1/auth/oauth/google/callback")
+}
+
+func TestLoginWithProviderCreatesUser(t *testing.T) {
+	fake, p := newProvider(t, auth.ExternalUser{Subject: "sub-1", Email: testEmail, Name: "Alice", EmailVerified: true})
+	s, users := newService(t, p)
+	ctx := context.Background()
+
+	if _, err := s.LoginWithProvider(ctx, p, fake.Code()); err != nil {
+		t.Fatalf("LoginWithProvider() error = %v", err)
+	}
+
+	u, err := users.FindByIdentity(ctx, testProvider, "sub-1")
+	if err != nil {
+		t.Fatalf("FindByIdentity() error = %v", err)
+	}
+
+	if u.Email != testEmail || !u.EmailVerified {
+		t.Fatalf("user = %+v, want the verified provider email", u)
+	}
+
+	// the second login finds the identity instead of creating another user.
+	if _, err := s.LoginWithProvider(ctx, p, fake.Code()); err != nil {
+		t.Fatalf("LoginWithProvider() error = %v", err)
+	}
+}
+
+func TestLoginWithProviderDropsUnverifiedEmail(t *testing.T) {
+	fake, p := newProvider(t, auth.ExternalUser{Subject: "sub-1", Email: testEmail})
+	s, users := newService(t, p)
+	ctx := context.Background()
+
+	if _, _, err := s.Register(ctx, testEmail, testPassword, ""); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, err := s.LoginWithProvider(ctx, p, fake.Code()); err != nil {
+		t.Fatalf("LoginWithProvider() error = %v", err)
+	}
+
+	u, err := users.FindByIdentity(ctx, testProvider, "sub-1")
+	if err != nil {
+		t.Fatalf("FindByIdentity() error = %v", err)
+	}
+
+	if u.Email != "" || u.PasswordHash != "" {
+		t.Fatalf("user = %+v, want a new user without email", u)
+	}
+}
+
+func TestLoginWithProviderLinksVerifiedEmail(t *testing.T) {
+	fake, p := newProvider(t, auth.ExternalUser{Subject: "google-sub", Email: testEmail, EmailVerified: true})
+	s, users := newService(t, p)
+	ctx := context.Background()
+
+	// a user created through another provider, whose email is verified.
+	other := fake.Config("discord", "http://localhost/v1/auth/oauth/discord/callback")
+	fake.SetUser(auth.ExternalUser{Subject: "discord-sub", Email: testEmail, EmailVerified: true})
+
+	if _, err := s.LoginWithProvider(ctx, other, fake.Code()); err != nil {
+		t.Fatalf("LoginWithProvider() error = %v", err)
+	}
+
+	fake.SetUser(auth.ExternalUser{Subject: "google-sub", Email: testEmail, EmailVerified: true})
+
+	if _, err := s.LoginWithProvider(ctx, p, fake.Code()); err != nil {
+		t.Fatalf("LoginWithProvider() error = %v", err)
+	}
+
+	u, err := users.FindByIdentity(ctx, testProvider, "google-sub")
+	if err != nil {
+		t.Fatalf("FindByIdentity() error = %v", err)
+	}
+
+	if len(u.Identities) != 2 {
+		t.Fatalf("identities = %+v, want both providers linked to one user", u.Identities)
+	}
+}
+
+func TestLoginWithProviderRefusesUnverifiedPasswordAccount(t *testing.T) {
+	fake, p := newProvider(t, auth.ExternalUser{Subject: "sub-1", Email: testEmail, EmailVerified: true})
+	s, users := newService(t, p)
+	ctx := context.Background()
+
+	// someone registered the victim's email first, without proving they own it.
+	if _, _, err := s.Register(ctx, testEmail, testPassword, ""); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	_, err := s.LoginWithProvider(ctx, p, fake.Code())
+	wantCode(t, err, appErr.ErrCodeConflict)
+
+	if _, err := users.FindByIdentity(ctx, testProvider, "sub-1"); !errors.Is(err, auth.ErrUserNotFound) {
+		t.Fatalf("FindByIdentity() error = %v, want the identity not linked", err)
+	}
+}
+
+func TestOAuthCallbackRejectsStateMismatch(t *testing.T) {
+	fake, p := newProvider(t, auth.ExternalUser{Subject: "sub-1"})
+	s, _ := newService(t, p)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var e *appErr.Error
+			if errors.As(err, &e) {
+				return c.SendStatus(e.Status())
+			}
+
+			return c.SendStatus(http.StatusInternalServerError)
+		},
+	})
+	auth.NewHandler(s, false).Install(app)
+
+	for _, tt := range []struct {
+		name   string
+		cookie string
+		state  string
+		want   int
+	}{
+		{name: "match", cookie: "expected", state: "expected", want: http.StatusOK},
+		{name: "mismatch", cookie: "expected", state: "forged", want: http.StatusUnauthorized},
+		{name: "no cookie", state: "forged", want: http.StatusUnauthorized},
+		{name: "empty", state: "", want: http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/auth/oauth/google/callback?code="+fake.Code()+"&state="+tt.state, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "oauth_state", Value: tt.cookie})
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Test() error = %v", err)
+			}
+
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
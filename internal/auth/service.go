@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oklog/ulid/v2"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+)
+
+// password length bounds; the upper bound keeps hashing cost predictable.
+const (
+	minPasswordLen = 8
+	maxPasswordLen = 128
+)
+
+// TokenPair is returned on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// Service registers and authenticates users.
+type Service struct {
+	users      UserRepository
+	refresh    RefreshTokenRepository
+	tokens     *TokenIssuer
+	refreshTTL time.Duration
+	providers  map[string]*Provider
+	now        func() time.Time
+
+	// dummyHash is verified when the user does not exist, so that the response time
+	// does not tell whether an email is registered.
+	dummyHash string
+}
+
+// NewService returns a service issuing tokens with tokens, and refresh tokens valid for refreshTTL.
+func NewService(users UserRepository, refresh RefreshTokenRepository, tokens *TokenIssuer, refreshTTL time.Duration, providers ...*Provider) (*Service, error) {
+	dummyHash, err := HashPassword("dummy password")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		users:      users,
+		refresh:    refresh,
+		tokens:     tokens,
+		refreshTTL: refreshTTL,
+		providers:  map[string]*Provider{},
+		now:        time.Now,
+		dummyHash:  dummyHash,
+	}
+
+	for _, p := range providers {
+		s.providers[p.Name] = p
+	}
+
+	return s, nil
+}
+
+// Tokens returns the access token issuer.
+func (s *Service) Tokens() *TokenIssuer {
+	return s.tokens
+}
+
+// Provider returns the OAuth provider named name, if it is configured.
+func (s *Service) Provider(name string) (*Provider, bool) {
+	p, ok := s.providers[name]
+	return p, ok
+}
+
+// Register creates a user with a password and logs them in.
+func (s *Service) Register(ctx context.Context, email, password, name string) (*User, *TokenPair, error) {
+	email = normalizeEmail(email)
+
+	var params []appErr.InvalidParam
+
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		params = append(params, appErr.InvalidParam{Name: "email", Reason: "must be a valid email address"})
+	}
+
+	if n := utf8.RuneCountInString(password); n < minPasswordLen || n > maxPasswordLen {
+		params = append(params, appErr.InvalidParam{
+			Name:   "password",
+			Reason: fmt.Sprintf("must be between %d and %d characters", minPasswordLen, maxPasswordLen),
+		})
+	}
+
+	if len(params) > 0 {
+		return nil, nil, appErr.Validation("", params...)
+	}
+
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	u := s.newUser(email, name)
+	u.PasswordHash = hash
+
+	if err := s.users.Create(ctx, u); err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			return nil, nil, appErr.Conflict("email already registered")
+		}
+
+		return nil, nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	pair, err := s.login(ctx, u, newID())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, pair, nil
+}
+
+// Login authenticates a user by email and password.
+func (s *Service) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	u, err := s.users.FindByEmail(ctx, normalizeEmail(email))
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	hash := s.dummyHash
+	if u != nil && u.PasswordHash != "" {
+		hash = u.PasswordHash
+	}
+
+	ok, err := VerifyPassword(password, hash)
+	if err != nil {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	if u == nil || u.PasswordHash == "" || !ok {
+		return nil, appErr.Unauthorized("invalid email or password")
+	}
+
+	return s.login(ctx, u, newID())
+}
+
+// LoginWithProvider logs in the user of an authorization code returned by p. Unknown accounts
+// with a verified email are linked to the user with the same email, unless that user has a
+// password and an unverified email, which is a conflict. Other accounts create a new user.
+func (s *Service) LoginWithProvider(ctx context.Context, p *Provider, code string) (*TokenPair, error) {
+	ext, err := p.Exchange(ctx, code)
+	if err != nil {
+		return nil, appErr.Wrap(appErr.ErrCodeUnauthorized, err, "")
+	}
+
+	identity := Identity{Provider: p.Name, Subject: ext.Subject}
+
+	u, err := s.users.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return s.login(ctx, u, newID())
+	}
+
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	email := normalizeEmail(ext.Email)
+
+	if email != "" && ext.EmailVerified {
+		u, err = s.users.FindByEmail(ctx, email)
+
+		switch {
+		case err == nil:
+			// the email was registered by whoever claimed it first: linking to an unverified
+			// account with a password would let that person log in to the provider's account.
+			if !u.EmailVerified && u.PasswordHash != "" {
+				return nil, appErr.Conflict("email already registered, log in with the password instead")
+			}
+
+			if err := s.users.AddIdentity(ctx, u.ID, identity); err != nil {
+				return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+			}
+
+			return s.login(ctx, u, newID())
+		case !errors.Is(err, ErrUserNotFound):
+			return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+		}
+	} else {
+		// an unverified email must not claim an address someone else may own.
+		email = ""
+	}
+
+	u = s.newUser(email, ext.Name)
+	u.EmailVerified = email != ""
+	u.Identities = []Identity{identity}
+
+	if err := s.users.Create(ctx, u); err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			return nil, appErr.Conflict("email already registered")
+		}
+
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return s.login(ctx, u, newID())
+}
+
+// Refresh rotates a refresh token, returning new tokens. Reusing a rotated token revokes
+// every token of its family, since it means the token leaked.
+func (s *Service) Refresh(ctx context.Context, raw string) (*TokenPair, error) {
+	t, err := s.refresh.FindByHash(ctx, hashRefreshToken(raw))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, appErr.Unauthorized("invalid refresh token")
+		}
+
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	now := s.now()
+
+	if t.RevokedAt != nil {
+		return nil, s.revokeFamily(ctx, t)
+	}
+
+	if !now.Before(t.ExpiresAt) {
+		return nil, appErr.Unauthorized("refresh token expired")
+	}
+
+	if err := s.refresh.Revoke(ctx, t.ID, now); err != nil {
+		if errors.Is(err, ErrTokenRevoked) {
+			return nil, s.revokeFamily(ctx, t)
+		}
+
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	u, err := s.users.FindByID(ctx, t.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, appErr.Unauthorized("invalid refresh token")
+		}
+
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return s.login(ctx, u, t.Family)
+}
+
+// Logout revokes every refresh token of the login raw belongs to.
+func (s *Service) Logout(ctx context.Context, raw string) error {
+	t, err := s.refresh.FindByHash(ctx, hashRefreshToken(raw))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil
+		}
+
+		return appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	if err := s.refresh.RevokeFamily(ctx, t.Family, s.now()); err != nil {
+		return appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return nil
+}
+
+// User returns the user with id.
+func (s *Service) User(ctx context.Context, id string) (*User, error) {
+	u, err := s.users.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, appErr.NotFound("")
+		}
+
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return u, nil
+}
+
+func (s *Service) revokeFamily(ctx context.Context, t *RefreshToken) error {
+	if err := s.refresh.RevokeFamily(ctx, t.Family, s.now()); err != nil {
+		return appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return appErr.Unauthorized("refresh token reused")
+}
+
+// login issues an access token and a refresh token of family for u.
+func (s *Service) login(ctx context.Context, u *User, family string) (*TokenPair, error) {
+	access, exp, err := s.tokens.Issue(u)
+	if err != nil {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	raw, hash, err := newRefreshToken()
+	if err != nil {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	now := s.now()
+
+	if err := s.refresh.Create(ctx, &RefreshToken{
+		ID:        newID(),
+		UserID:    u.ID,
+		Family:    family,
+		Hash:      hash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, appErr.Wrap(appErr.ErrCodeInternal, err, "")
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(exp.Sub(now).Seconds()),
+		RefreshToken: raw,
+	}, nil
+}
+
+func (s *Service) newUser(email, name string) *User {
+	now := s.now()
+
+	return &User{
+		ID:         newID(),
+		Email:      email,
+		Name:       strings.TrimSpace(name),
+		Identities: []Identity{},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func newID() string {
+	// crypto/rand.Reader is safe for concurrent use, unlike ulid.Monotonic.
+	return ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+}
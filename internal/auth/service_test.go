@@ -0,0 +1,180 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth/authtest"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+)
+
+const (
+	testEmail    = "alice@example.com"
+	testPassword = "correct horse battery"
+)
+
+func newService(t *testing.T, providers ...*auth.Provider) (*auth.Service, *authtest.Users) {
+	t.Helper()
+
+	tokens, err := auth.NewTokenIssuer([]byte("0123456789abcdef0123456789abcdef"), "test", time.Minute)
+	if err != nil {
+		t.Fatalf("NewTokenIssuer() error = %v", err)
+	}
+
+	users := authtest.NewUsers()
+
+	s, err := auth.NewService(users, authtest.NewRefreshTokens(), tokens, time.Hour, providers...)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	return s, users
+}
+
+// wantCode fails t unless err is a managed error with code.
+func wantCode(t *testing.T, err error, code appErr.ErrCode) {
+	t.Helper()
+
+	if !errors.Is(err, &appErr.Error{Code: code}) {
+		t.Fatalf("error = %v, want %s", err, code)
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	s, _ := newService(t)
+	ctx := context.Background()
+
+	u, pair, err := s.Register(ctx, " Alice@Example.com ", testPassword, "Alice")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if u.Email != testEmail || u.EmailVerified || pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Fatalf("Register() = %+v, %+v", u, pair)
+	}
+
+	claims, err := s.Tokens().Parse(pair.AccessToken)
+	if err != nil || claims.Subject != u.ID {
+		t.Fatalf("Parse() = %+v, %v, want the subject %s", claims, err, u.ID)
+	}
+
+	if _, err := s.Login(ctx, testEmail, testPassword); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	_, _, err = s.Register(ctx, testEmail, testPassword, "")
+	wantCode(t, err, appErr.ErrCodeConflict)
+}
+
+func TestRegisterValidates(t *testing.T) {
+	s, _ := newService(t)
+
+	_, _, err := s.Register(context.Background(), "not an email", "short", "")
+	wantCode(t, err, appErr.ErrCodeValidation)
+}
+
+func TestLoginFailuresLookAlike(t *testing.T) {
+	s, _ := newService(t)
+	ctx := context.Background()
+
+	if _, _, err := s.Register(ctx, testEmail, testPassword, ""); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// an unknown email verifies the dummy hash, and must fail exactly like a wrong password.
+	_, wrongPassword := s.Login(ctx, testEmail, "wrong password")
+	_, unknownEmail := s.Login(ctx, "bob@example.com", testPassword)
+
+	wantCode(t, wrongPassword, appErr.ErrCodeUnauthorized)
+	wantCode(t, unknownEmail, appErr.ErrCodeUnauthorized)
+
+	if wrongPassword.Error() != unknownEmail.Error() {
+		t.Fatalf("errors differ: %q and %q", wrongPassword, unknownEmail)
+	}
+}
+
+func TestRefreshRotates(t *testing.T) {
+	s, _ := newService(t)
+	ctx := context.Background()
+
+	_, first, err := s.Register(ctx, testEmail, testPassword, "")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("Refresh() returned the same refresh token")
+	}
+
+	if _, err := s.Refresh(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("Refresh() of the rotated token error = %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	s, _ := newService(t)
+	ctx := context.Background()
+
+	_, first, err := s.Register(ctx, testEmail, testPassword, "")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// another login is another family, which the reuse must not revoke.
+	other, err := s.Login(ctx, testEmail, testPassword)
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	_, err = s.Refresh(ctx, first.RefreshToken)
+	wantCode(t, err, appErr.ErrCodeUnauthorized)
+
+	_, err = s.Refresh(ctx, second.RefreshToken)
+	wantCode(t, err, appErr.ErrCodeUnauthorized)
+
+	if _, err := s.Refresh(ctx, other.RefreshToken); err != nil {
+		t.Fatalf("Refresh() of another family error = %v", err)
+	}
+}
+
+func TestRefreshUnknownToken(t *testing.T) {
+	s, _ := newService(t)
+
+	_, err := s.Refresh(context.Background(), "unknown")
+	wantCode(t, err, appErr.ErrCodeUnauthorized)
+}
+
+func TestLogoutRevokesFamily(t *testing.T) {
+	s, _ := newService(t)
+	ctx := context.Background()
+
+	_, first, err := s.Register(ctx, testEmail, testPassword, "")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	second, err := s.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if err := s.Logout(ctx, first.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+
+	_, err = s.Refresh(ctx, second.RefreshToken)
+	wantCode(t, err, appErr.ErrCodeUnauthorized)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// minSecretLen is the minimum length of the HS256 signing secret.
+const minSecretLen = 32
+
+var errInvalidToken = errors.New("invalid access token")
+
+// Claims are the claims of access tokens. The subject is the user ID.
+type Claims struct {
+	Email string `json:"email,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenIssuer signs and verifies access tokens.
+type TokenIssuer struct {
+	secret []byte
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenIssuer returns an issuer of HS256 access tokens valid for ttl.
+func NewTokenIssuer(secret []byte, issuer string, ttl time.Duration) (*TokenIssuer, error) {
+	if len(secret) < minSecretLen {
+		return nil, fmt.Errorf("signing secret must be at least %d bytes", minSecretLen)
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid access token ttl: %s", ttl)
+	}
+
+	return &TokenIssuer{secret: secret, issuer: issuer, ttl: ttl, now: time.Now}, nil
+}
+
+// Issue returns a signed access token for u and its expiry.
+func (t *TokenIssuer) Issue(u *User) (string, time.Time, error) {
+	now := t.now()
+	exp := now.Add(t.ttl)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		Email: u.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.issuer,
+			Subject:   u.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	})
+
+	signed, err := token.SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	return signed, exp, nil
+}
+
+// Parse verifies token and returns its claims.
+func (t *TokenIssuer) Parse(token string) (*Claims, error) {
+	claims := new(Claims)
+
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if _, err := parser.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}); err != nil {
+		return nil, fmt.Errorf("%s: %w", err.Error(), errInvalidToken)
+	}
+
+	if !claims.VerifyIssuer(t.issuer, true) || claims.Subject == "" {
+		return nil, errInvalidToken
+	}
+
+	return claims, nil
+}
+
+// newRefreshToken returns a random refresh token and the hash stored in its place.
+func newRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	return raw, hashRefreshToken(raw), nil
+}
+
+// hashRefreshToken hashes a refresh token; tokens are random, so a fast hash suffices.
+func hashRefreshToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// errors returned by repositories.
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrEmailTaken    = errors.New("email already registered")
+	ErrTokenNotFound = errors.New("refresh token not found")
+	ErrTokenRevoked  = errors.New("refresh token already revoked")
+)
+
+// Identity links a user to an account of an OAuth provider.
+type Identity struct {
+	Provider string `json:"provider" bson:"provider"`
+	Subject  string `json:"-" bson:"subject"`
+}
+
+// User is an account of the application.
+type User struct {
+	ID    string `json:"id" bson:"_id"`
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	Name  string `json:"name" bson:"name"`
+
+	// EmailVerified tells whether the owner of Email proved it, e.g. through a provider
+	// verifying it. Emails registered with a password are not verified.
+	EmailVerified bool `json:"emailVerified" bson:"email_verified"`
+
+	// PasswordHash is empty for users who only log in through OAuth providers.
+	PasswordHash string `json:"-" bson:"password_hash,omitempty"`
+
+	Identities []Identity `json:"identities" bson:"identities"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"created_at"`
+	UpdatedAt  time.Time  `json:"updatedAt" bson:"updated_at"`
+}
+
+// UserRepository stores users.
+type UserRepository interface {
+	// Create stores u, returning ErrEmailTaken if its email is already registered.
+	Create(ctx context.Context, u *User) error
+	FindByID(ctx context.Context, id string) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByIdentity(ctx context.Context, provider, subject string) (*User, error)
+	AddIdentity(ctx context.Context, userID string, identity Identity) error
+}
+
+// RefreshToken is a long-lived token exchanged for new access tokens. Only its hash is stored.
+// Every use rotates it; tokens rotated from the same login share a family, which is revoked
+// as a whole when a rotated token is reused.
+type RefreshToken struct {
+	ID        string     `bson:"_id"`
+	UserID    string     `bson:"user_id"`
+	Family    string     `bson:"family"`
+	Hash      string     `bson:"hash"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty"`
+}
+
+// RefreshTokenRepository stores refresh tokens.
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t *RefreshToken) error
+	// FindByHash returns ErrTokenNotFound when there is no token with hash.
+	FindByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// Revoke revokes the token, returning ErrTokenRevoked if it was already revoked.
+	Revoke(ctx context.Context, id string, at time.Time) error
+	RevokeFamily(ctx context.Context, family string, at time.Time) error
+}
@@ -0,0 +1,68 @@
+package di
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+)
+
+type setLogLevelRequest struct {
+	// Name is the logger name; empty means the global level.
+	Name  string `json:"name"`
+	Level string `json:"level"`
+
+	// Duration reverts the change after it elapses, e.g. "10m". Empty means permanent.
+	Duration string `json:"duration"`
+}
+
+// adminAuth rejects requests without the admin bearer token.
+// Every request is rejected when no token is configured.
+func (s *Server) adminAuth(c *fiber.Ctx) error {
+	token := di.GetConfig().Admin.Token
+	given := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(given)) != 1 {
+		return c.SendStatus(http.StatusUnauthorized)
+	}
+
+	return c.Next()
+}
+
+func (s *Server) getLogLevels(c *fiber.Ctx) error {
+	return c.JSON(logging.GetLevels().Snapshot())
+}
+
+func (s *Server) setLogLevel(c *fiber.Ctx) error {
+	var req setLogLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	var ttl time.Duration
+
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			return fiber.NewError(http.StatusBadRequest, "invalid duration: "+req.Duration)
+		}
+
+		ttl = d
+	}
+
+	if err := logging.GetLevels().SetNamed(req.Name, req.Level, ttl); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	return c.JSON(logging.GetLevels().Snapshot())
+}
+
+func (s *Server) resetLogLevel(c *fiber.Ctx) error {
+	logging.GetLevels().ResetNamed(c.Params("name"))
+
+	return c.JSON(logging.GetLevels().Snapshot())
+}
@@ -0,0 +1,80 @@
+package di
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/infrastructure/datastore"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// KeyAuth is the container key of the authentication handler.
+const KeyAuth di.Key = "auth"
+
+// indexTimeout bounds the creation of the auth collection indexes at startup.
+const indexTimeout = 30 * time.Second
+
+func init() {
+	di.Register(KeyAuth, provideAuth)
+}
+
+func provideAuth(r di.Resolver) (interface{}, error) {
+	v, err := r.Get(datastore.KeyDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	db := v.(*mongo.Database)
+	conf := di.GetConfig()
+
+	secret := []byte(conf.Auth.JWTSecret)
+	if len(secret) == 0 {
+		// only allowed outside cloud environments, see config.Config.Validate.
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate jwt secret: %w", err)
+		}
+
+		di.GetLogger().Named("auth").Warn("AUTH_JWT_SECRET is unset, tokens are invalidated on restart")
+	}
+
+	tokens, err := auth.NewTokenIssuer(secret, conf.Auth.Issuer, conf.Auth.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	users := datastore.NewUserRepo(db)
+	refresh := datastore.NewRefreshTokenRepo(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), indexTimeout)
+	defer cancel()
+
+	if err := users.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := refresh.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	var providers []*auth.Provider
+
+	if c := conf.Auth; c.DiscordClientID != "" {
+		providers = append(providers, auth.Discord(c.DiscordClientID, c.DiscordClientSecret, c.DiscordRedirectURL))
+	}
+
+	if c := conf.Auth; c.GoogleClientID != "" {
+		providers = append(providers, auth.Google(c.GoogleClientID, c.GoogleClientSecret, c.GoogleRedirectURL))
+	}
+
+	service, err := auth.NewService(users, refresh, tokens, conf.Auth.RefreshTTL, providers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.NewHandler(service, conf.AppEnv.IsCloud()), nil
+}
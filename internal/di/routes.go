@@ -1,18 +1,31 @@
 package di
 
-import iDI "github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
-
+import (
+	iDI "github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/health"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/metrics"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/tracing"
+)
 
 func (s *Server) setupRoutes() {
 	logger := iDI.GetContextLogger()
-	s.server.Use(s.handleError)
+	s.server.Use(metrics.Instrument)
+	s.server.Use(tracing.Middleware)
+	s.server.Use(logging.RequestID)
 
-	s.server.Use(logger.Access)
+	// health checks are polled constantly, so they are not access logged.
+	// Access wraps handleError, so that it logs error responses as rendered.
+	s.server.Use(logger.SkipAccess("/", "/healthz", "/readyz").Access)
+	s.server.Use(s.handleError)
 
 	s.server.Get("/", s.healthCheck)
+	s.server.Get("/healthz", s.health.Handler(health.Liveness))
+	s.server.Get("/readyz", s.health.Handler(health.Readiness))
 
 	{
-		v1 := s.server.Group("/v1")
+		v1 := s.server.Group("/v1", s.auth.Authenticate)
+		s.auth.Install(v1)
 
 		// s.installBot(v1, &honda.Honda{})
 		// s.installBot(v1, &curves.Curves{})
@@ -32,4 +45,18 @@ func (s *Server) setupRoutes() {
 
 		// v1.Use("/swagger", filesystem.New(filesystem.Config{Root: docs.SwaggerAPI()}))
 	}
-}
\ No newline at end of file
+
+	// must be registered last: renders unmatched routes through handleError.
+	s.server.Use(s.notFound)
+}
+
+func (s *Server) setupAdminRoutes() {
+	s.admin.Get("/metrics", metrics.Handler())
+
+	{
+		log := s.admin.Group("/log", s.adminAuth)
+		log.Get("/levels", s.getLogLevels)
+		log.Put("/levels", s.setLogLevel)
+		log.Delete("/levels/:name", s.resetLogLevel)
+	}
+}
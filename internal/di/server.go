@@ -1,48 +1,267 @@
 package di
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/infrastructure/datastore"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
 	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/health"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/i18n"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/metrics"
 	"go.uber.org/zap"
 )
 
 // Server object
 type Server struct {
 	server *fiber.App
+
+	// health serves /healthz and /readyz, and fails readiness on shutdown.
+	health *health.Registry
+
+	auth *auth.Handler
+
+	// admin serves operational endpoints (metrics) on a separate port.
+	admin *fiber.App
+}
+
+// KeyServer is the container key of the API server.
+const KeyServer di.Key = "server"
+
+func init() {
+	di.Register(KeyServer, provideServer)
+}
+
+// GetServer builds the server and its dependencies; di.Run starts it.
+func GetServer() *Server {
+	v, err := di.Default().Get(KeyServer)
+	if err != nil {
+		di.LogInitFatal("server", err)
+	}
+
+	return v.(*Server)
 }
 
 func newService() *Server {
 	return &Server{
 		server: fiber.New(),
+		admin:  fiber.New(fiber.Config{DisableStartupMessage: true}),
 	}
 }
 
-func (s *Server) start() {
+func provideServer(r di.Resolver) (interface{}, error) {
+	// the tracer, config watcher and database must be built before the server, so that they are
+	// started before it and stopped after it.
+	for _, key := range []di.Key{di.KeyTracer, di.KeyConfigWatcher, datastore.KeyDatabase} {
+		if _, err := r.Get(key); err != nil {
+			return nil, err
+		}
+	}
+
+	v, err := r.Get(di.KeyHealth)
+	if err != nil {
+		return nil, err
+	}
+
+	h := v.(*health.Registry)
+
+	v, err = r.Get(KeyAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newService()
+	s.health = h
+	s.auth = v.(*auth.Handler)
 	s.setupRoutes()
+	s.setupAdminRoutes()
+
+	r.OnStart(func(context.Context) error {
+		conf := di.GetConfig()
+
+		// both ports are bound before serving, so that e.g. a port in use fails the start.
+		server, err := listen(s.server, conf.Server.Addr)
+		if err != nil {
+			return err
+		}
+
+		admin, err := listen(s.admin, conf.Admin.Addr)
+		if err != nil {
+			_ = server.Close()
+			return err
+		}
+
+		s.serve("server", s.server, server)
+		s.serve("admin", s.admin, admin)
+
+		return nil
+	})
+	r.OnStop(func(ctx context.Context) error {
+		s.health.Shutdown()
+		s.drain(ctx, di.GetConfig().Server.ShutdownDrain)
+
+		return s.shutdown(ctx)
+	})
+
+	return s, nil
 }
 
+// listen binds addr on the network app is configured for.
+func listen(app *fiber.App, addr string) (net.Listener, error) {
+	ln, err := net.Listen(app.Config().Network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	return ln, nil
+}
+
+func (s *Server) serve(name string, app *fiber.App, ln net.Listener) {
+	go func() {
+		if err := app.Listener(ln); err != nil {
+			di.GetLogger().Named(name).Error(name+" server stopped", zap.Error(err))
+		}
+	}()
+}
+
+// drain keeps serving for d, or until ctx is done, while readiness reports the shutdown.
+func (s *Server) drain(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	di.GetLogger().Named("server").Info(fmt.Sprintf("draining for %s before closing the listener", d))
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// shutdown stops both servers, waiting for in-flight requests until ctx is done.
+func (s *Server) shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+
+	go func() {
+		if err := s.server.Shutdown(); err != nil {
+			done <- err
+			return
+		}
+
+		done <- s.admin.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("failed to shut down servers: %w", ctx.Err())
+	}
+}
+
+// healthCheck is kept for load balancers polling "/"; it reports liveness only.
 func (s *Server) healthCheck(ctx *fiber.Ctx) error {
+	if s.health.Run(ctx.UserContext(), health.Liveness).Status == health.StatusDown {
+		return ctx.SendStatus(http.StatusServiceUnavailable)
+	}
+
 	return ctx.SendStatus(http.StatusOK)
 }
 
+// notFound renders unmatched routes. Fiber reports paths served for other methods
+// as fiber.ErrMethodNotAllowed once the stack is exhausted, which is kept.
+func (s *Server) notFound(ctx *fiber.Ctx) error {
+	// the stack is exhausted, so no route matched.
+	err := ctx.Next()
+	metrics.SetUnmatched(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return appErr.NotFound("")
+}
+
 func (s *Server) handleError(c *fiber.Ctx) (err error) {
 	err = c.Next()
+	if err == nil {
+		return nil
+	}
+
 	logger := di.GetContextLogger()
 
 	// managed error
 	var managed *appErr.Error
 	if errors.As(err, &managed) {
-		c.Status(http.StatusBadRequest)
-		return c.JSON(managed)
-	} else if err != nil {
-		logger.Error(c, "Received unmanaged error", zap.Error(err))
+		if managed.Status() >= http.StatusInternalServerError {
+			logger.Error(c, "Received managed server error",
+				zap.Error(err),
+				zap.Strings("causes", appErr.Chain(err)),
+				zap.String("errorStack", managed.StackTrace()),
+			)
+		}
+
+		return s.renderError(c, managed)
 	}
 
-	return err
+	// errors raised by fiber itself, e.g. fiber.ErrMethodNotAllowed. Their default message is
+	// replaced by the one registered for the code, so that it is localized like the others.
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		message := fe.Message
+		if message == utils.StatusMessage(fe.Code) {
+			message = ""
+		}
+
+		return s.renderError(c, appErr.New(appErr.CodeForStatus(fe.Code), message, nil))
+	}
+
+	logger.Error(c, "Received unmanaged error", zap.Error(err), zap.Strings("causes", appErr.Chain(err)))
+
+	return s.renderError(c, appErr.Internal(""))
+}
+
+// renderError writes e as problem+json when the client asks for it, and in the legacy
+// {code,message,data} shape otherwise. Default messages are localized from Accept-Language.
+func (s *Server) renderError(c *fiber.Ctx, e *appErr.Error) error {
+	title, lang := i18n.ErrorMessage(i18n.Match(c.Get(fiber.HeaderAcceptLanguage)), e.Code)
+
+	localized := *e
+	if e.Message == e.Code.Message() {
+		localized.Message = title
+	}
+
+	c.Status(e.Status())
+	c.Set(fiber.HeaderContentLanguage, lang.String())
+	logging.SetErrorCode(c, e.Code)
+
+	// the legacy shape is offered first, so that clients accepting anything keep getting it.
+	if c.Accepts(fiber.MIMEApplicationJSON, appErr.ProblemContentType) != appErr.ProblemContentType {
+		return c.JSON(&localized)
+	}
+
+	p := localized.Problem(logging.RequestIDFromContext(c.UserContext()))
+	p.Title = title
+
+	if err := c.JSON(p); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, appErr.ProblemContentType)
+
+	return nil
 }
 
 // func (s *server) installBot(r fiber.Router, sc model.Bot) {
@@ -3,64 +3,61 @@ package datastore
 import (
 	"context"
 	"fmt"
-	"io"
 
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/config"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/di"
-	"github.com/puipuipartpicker/kbpartpicker/api/pkg/env"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/health"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/metrics"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 	"go.uber.org/zap"
 )
 
-const (
-	envAppEnv           env.VarName = "APP_ENV"
-	envDatabaseURI      env.VarName = "DB_URI"
-	envDatabaseUsername env.VarName = "DB_USERNAME"
-	envDatabasePassword env.VarName = "DB_PASSWORD"
-	envDatabaseName     env.VarName = "DB_NAME"
-)
+// KeyDatabase is the container key of the mongo database.
+const KeyDatabase di.Key = "mongo.database"
 
-var (
-	db *mongo.Database
-)
+func init() {
+	di.Register(KeyDatabase, provideDatabase)
+}
 
 // NewBaseRepo returns a base repository.
 func NewBaseRepo(db *mongo.Database) *BaseRepo {
-	return &BaseRepo{db: db}
+	return &BaseRepo{db: db, logger: di.GetCtxLogger().Named("repository")}
 }
 
 type BaseRepo struct {
 	db *mongo.Database
-}
-
-type wrapClient struct {
-	client *mongo.Client
-}
 
-func (w *wrapClient) Close() error {
-	return w.client.Disconnect(context.Background())
+	// logger logs with the request fields carried by the context passed to repository methods.
+	logger logging.CtxLogger
 }
 
 // GetClient returns mongo database client.
 func GetClient(ctx context.Context) (*mongo.Client, string, error) {
-	if GetAppEnv().IsTest() {
-		client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	conf := di.GetConfig()
+
+	if conf.AppEnv.IsTest() {
+		client, err := mongo.Connect(ctx, options.Client().
+			ApplyURI("mongodb://localhost:27017").
+			SetMonitor(otelmongo.NewMonitor()))
 
 		return client, "test", err
 	}
 
-	database := env.String(envDatabaseName)
+	database := conf.Database.Name
 
-	clientOpts := options.Client().ApplyURI(env.String(envDatabaseURI))
+	clientOpts := options.Client().ApplyURI(conf.Database.URI)
 	clientOpts.SetReadPreference(readpref.Primary())
+	clientOpts.SetMonitor(otelmongo.NewMonitor())
 	clientOpts.SetAuth(options.Credential{
 		AuthSource: database,
-		Username:   env.String(envDatabaseUsername),
-		Password:   env.String(envDatabasePassword),
+		Username:   conf.Database.Username,
+		Password:   conf.Database.Password,
 	})
 
 	client, err := mongo.Connect(ctx, clientOpts)
@@ -70,32 +67,50 @@ func GetClient(ctx context.Context) (*mongo.Client, string, error) {
 
 // GetDatabase returns mongo database connection.
 func GetDatabase() *mongo.Database {
-	if db != nil {
-		return db
+	v, err := di.Default().Get(KeyDatabase)
+	if err != nil {
+		di.LogInitFatal("mongo database", err)
 	}
 
-	ctx := context.Background()
+	return v.(*mongo.Database)
+}
 
-	client, database, err := GetClient(ctx)
+// provideDatabase connects to mongo and disconnects on stop.
+func provideDatabase(r di.Resolver) (interface{}, error) {
+	v, err := r.Get(di.KeyLogger)
 	if err != nil {
-		di.LogInitFatal("failed to connect mongo", err)
+		return nil, err
 	}
 
-	l := di.GetLogger().Named("repository")
+	l := v.(logging.Logger).Named("repository")
 
-	if err := pingDatabase(ctx, l, client); err != nil {
-		di.LogInitFatal("failed to ping database", err)
+	v, err = r.Get(di.KeyHealth)
+	if err != nil {
+		return nil, err
 	}
 
-	var c io.Closer = &wrapClient{
-		client: client,
+	checks := v.(*health.Registry)
+
+	ctx := context.Background()
+
+	client, database, err := GetClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect mongo: %w", err)
+	}
+
+	if err := pingDatabase(ctx, l, client); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	di.RegisterCloser("MongoDB connection", c)
+	checks.AddReadiness("mongo", func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	})
 
-	db = client.Database(database)
+	r.OnStop(func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	})
 
-	return db
+	return client.Database(database), nil
 }
 
 // createFilter returns basic mongo bson.M filter (include option to use soft delete or not).
@@ -113,25 +128,25 @@ func createFilter(ignoreDeletedDocument bool) bson.M {
 	return filter
 }
 
-func GetAppEnv() env.AppEnv {
-	return env.Env(envAppEnv, env.EnvTest)
-}
-
 func pingDatabase(ctx context.Context, logger logging.Logger, client *mongo.Client) error {
-	// use default configuration
-	retrier, err := retry.New()
+	retrier, err := retry.NewNamed(config.RetryPolicyMongoPing,
+		retry.WithHook(metrics.RetryHook(config.RetryPolicyMongoPing)),
+		retry.WithHook(func(a retry.Attempt) {
+			logger.Warn(fmt.Sprintf("failed to ping to database, try count %d", a.Number),
+				zap.Duration("elapsed", a.Elapsed),
+				zap.Duration("nextDelay", a.NextDelay),
+				zap.Error(a.Err),
+			)
+		}),
+		retry.WithRetryIf(retry.IsRetryableMongo),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to initialize retrier: %w", err)
 	}
 
-	count := 1
-
 	err = retrier.Do(ctx, func(ctx context.Context) error {
 		if err := client.Ping(ctx, readpref.Primary()); err != nil {
-			logger.Warn(fmt.Sprintf("failed to ping to database, try count %d", count), zap.Error(err))
-			count++
-
-			return fmt.Errorf("failed to ping to database, try count %d: %w", count, err)
+			return fmt.Errorf("failed to ping to database: %w", err)
 		}
 
 		return nil
@@ -142,4 +157,3 @@ func pingDatabase(ctx context.Context, logger logging.Logger, client *mongo.Clie
 
 	return nil
 }
-
@@ -0,0 +1,100 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const refreshTokensCollection = "refresh_tokens"
+
+// RefreshTokenRepo stores refresh tokens in the refresh_tokens collection.
+type RefreshTokenRepo struct {
+	*BaseRepo
+}
+
+// NewRefreshTokenRepo returns a refresh token repository.
+func NewRefreshTokenRepo(db *mongo.Database) *RefreshTokenRepo {
+	return &RefreshTokenRepo{BaseRepo: NewBaseRepo(db)}
+}
+
+// EnsureIndexes creates the token hash and family indexes, and expires tokens once invalid.
+func (r *RefreshTokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "family", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s indexes: %w", refreshTokensCollection, err)
+	}
+
+	return nil
+}
+
+// Create implements auth.RefreshTokenRepository.
+func (r *RefreshTokenRepo) Create(ctx context.Context, t *auth.RefreshToken) error {
+	if _, err := r.collection().InsertOne(ctx, t); err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// FindByHash implements auth.RefreshTokenRepository.
+func (r *RefreshTokenRepo) FindByHash(ctx context.Context, hash string) (*auth.RefreshToken, error) {
+	t := new(auth.RefreshToken)
+
+	if err := r.collection().FindOne(ctx, bson.M{"hash": hash}).Decode(t); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, auth.ErrTokenNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+
+	return t, nil
+}
+
+// Revoke implements auth.RefreshTokenRepository. The filter on revoked_at makes concurrent
+// rotations of the same token fail but one.
+func (r *RefreshTokenRepo) Revoke(ctx context.Context, id string, at time.Time) error {
+	res, err := r.collection().UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+
+	if res.ModifiedCount == 0 {
+		return auth.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeFamily implements auth.RefreshTokenRepository.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, family string, at time.Time) error {
+	res, err := r.collection().UpdateMany(ctx,
+		bson.M{"family": family, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	r.logger.Info(ctx, fmt.Sprintf("revoked %d refresh tokens", res.ModifiedCount))
+
+	return nil
+}
+
+func (r *RefreshTokenRepo) collection() *mongo.Collection {
+	return r.db.Collection(refreshTokensCollection)
+}
@@ -0,0 +1,112 @@
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/internal/auth"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const usersCollection = "users"
+
+// UserRepo stores users in the users collection.
+type UserRepo struct {
+	*BaseRepo
+}
+
+// NewUserRepo returns a user repository.
+func NewUserRepo(db *mongo.Database) *UserRepo {
+	return &UserRepo{BaseRepo: NewBaseRepo(db)}
+}
+
+// EnsureIndexes creates the unique indexes on emails and provider identities.
+func (r *UserRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			// users created through OAuth providers may have no email.
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$type": "string"}}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s indexes: %w", usersCollection, err)
+	}
+
+	return nil
+}
+
+// Create implements auth.UserRepository.
+func (r *UserRepo) Create(ctx context.Context, u *auth.User) error {
+	if _, err := r.collection().InsertOne(ctx, u); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return auth.ErrEmailTaken
+		}
+
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	r.logger.Info(ctx, "user created")
+
+	return nil
+}
+
+// FindByID implements auth.UserRepository.
+func (r *UserRepo) FindByID(ctx context.Context, id string) (*auth.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByEmail implements auth.UserRepository.
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*auth.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+// FindByIdentity implements auth.UserRepository.
+func (r *UserRepo) FindByIdentity(ctx context.Context, provider, subject string) (*auth.User, error) {
+	return r.findOne(ctx, bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}})
+}
+
+// AddIdentity implements auth.UserRepository.
+func (r *UserRepo) AddIdentity(ctx context.Context, userID string, identity auth.Identity) error {
+	res, err := r.collection().UpdateByID(ctx, userID, bson.M{
+		"$addToSet": bson.M{"identities": identity},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add identity: %w", err)
+	}
+
+	if res.MatchedCount == 0 {
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (r *UserRepo) findOne(ctx context.Context, filter bson.M) (*auth.User, error) {
+	u := new(auth.User)
+
+	if err := r.collection().FindOne(ctx, filter).Decode(u); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, auth.ErrUserNotFound
+		}
+
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (r *UserRepo) collection() *mongo.Collection {
+	return r.db.Collection(usersCollection)
+}
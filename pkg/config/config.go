@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/env"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/tracing"
+)
+
+// Config is the application configuration.
+type Config struct {
+	AppEnv env.AppEnv `env:"APP_ENV" default:"test"`
+
+	// GoogleCloudProject is used to build Cloud Logging trace IDs.
+	GoogleCloudProject string `env:"GOOGLE_CLOUD_PROJECT"`
+
+	Server   ServerConfig   `prefix:"SERVER_"`
+	Admin    AdminConfig    `prefix:"ADMIN_"`
+	Log      LogConfig      `prefix:"LOG_"`
+	Trace    TraceConfig    `prefix:"TRACE_"`
+	Database DatabaseConfig `prefix:"DB_"`
+	Retry    RetryConfig    `prefix:"RETRY_"`
+	Auth     AuthConfig     `prefix:"AUTH_"`
+}
+
+// ServerConfig configures the API server.
+type ServerConfig struct {
+	Addr string `env:"ADDR" default:":8080"`
+
+	// ShutdownDrain is the time readiness fails before the listener closes on shutdown,
+	// so that load balancers stop routing requests to the instance first.
+	ShutdownDrain time.Duration `env:"SHUTDOWN_DRAIN" default:"5s"`
+}
+
+// AdminConfig configures the admin server.
+type AdminConfig struct {
+	Addr string `env:"ADDR" default:":9090"`
+
+	// Token authenticates admin requests; every request is rejected when it is empty.
+	Token string `env:"TOKEN" secret:"true" reload:"true"`
+}
+
+// LogConfig configures loggers.
+type LogConfig struct {
+	Level              string           `env:"LEVEL" default:"info" reload:"true"`
+	Encoding           logging.Encoding `env:"ENCODING" default:"json"`
+	Output             logging.Output   `env:"OUTPUT" default:"stdout"`
+	FilePath           string           `env:"FILE_PATH" default:"/var/log/kbpartpicker/api.log"`
+	FileMaxSizeMB      int              `env:"FILE_MAX_SIZE_MB" default:"100"`
+	FileMaxBackups     int              `env:"FILE_MAX_BACKUPS" default:"5"`
+	FileMaxAgeDays     int              `env:"FILE_MAX_AGE_DAYS" default:"7"`
+	SamplingInitial    int              `env:"SAMPLING_INITIAL" default:"0"`
+	SamplingThereafter int              `env:"SAMPLING_THEREAFTER" default:"100"`
+	CloudLogging       bool             `env:"CLOUD_LOGGING" default:"false"`
+}
+
+// TraceConfig configures span export.
+type TraceConfig struct {
+	Exporter tracing.Exporter `env:"EXPORTER" default:"none"`
+	Endpoint string           `env:"ENDPOINT"`
+	Insecure bool             `env:"INSECURE" default:"false"`
+}
+
+// DatabaseConfig configures the MongoDB connection. It is required in cloud environments.
+type DatabaseConfig struct {
+	URI      string `env:"URI" secret:"true"`
+	Username string `env:"USERNAME"`
+	Password string `env:"PASSWORD" secret:"true"`
+	Name     string `env:"NAME"`
+}
+
+// minJWTSecretLen is the minimum length of AUTH_JWT_SECRET for HS256.
+const minJWTSecretLen = 32
+
+// AuthConfig configures authentication. OAuth providers are enabled when their client ID is set.
+type AuthConfig struct {
+	// JWTSecret signs access tokens; at least 32 bytes, required in cloud environments.
+	JWTSecret  string        `env:"JWT_SECRET" secret:"true"`
+	Issuer     string        `env:"ISSUER" default:"kbpartpicker"`
+	AccessTTL  time.Duration `env:"ACCESS_TTL" default:"15m"`
+	RefreshTTL time.Duration `env:"REFRESH_TTL" default:"720h"`
+
+	DiscordClientID     string `env:"DISCORD_CLIENT_ID"`
+	DiscordClientSecret string `env:"DISCORD_CLIENT_SECRET" secret:"true"`
+	DiscordRedirectURL  string `env:"DISCORD_REDIRECT_URL"`
+
+	GoogleClientID     string `env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `env:"GOOGLE_CLIENT_SECRET" secret:"true"`
+	GoogleRedirectURL  string `env:"GOOGLE_REDIRECT_URL"`
+}
+
+// RetryConfig holds the retry policies of subsystems, e.g. "initial=50ms,factor=2,max=8,timeout=30s".
+type RetryConfig struct {
+	MongoPing retry.Policy `env:"MONGO_PING" default:"initial=10ms,factor=1.6,jitter=0.2,max=5,timeout=3m"`
+}
+
+// retry policy names, looked up with retry.NewNamed.
+const (
+	RetryPolicyMongoPing = "mongo_ping"
+)
+
+// Validate checks values that depend on each other or on a fixed set of choices.
+func (c *Config) Validate() error {
+	var errs Errors
+
+	switch c.AppEnv {
+	case env.EnvTest, env.EnvDev, env.EnvStg, env.EnvPrd:
+	default:
+		errs = append(errs, &FieldError{Key: "APP_ENV", Err: fmt.Errorf("unknown environment %q", c.AppEnv)})
+	}
+
+	if err := logging.ValidateLevel(c.Log.Level); err != nil {
+		errs = append(errs, &FieldError{Key: "LOG_LEVEL", Err: err})
+	}
+
+	switch c.Log.Encoding {
+	case logging.EncodingJSON, logging.EncodingConsole:
+	default:
+		errs = append(errs, &FieldError{Key: "LOG_ENCODING", Err: fmt.Errorf("unknown encoding %q", c.Log.Encoding)})
+	}
+
+	switch c.Log.Output {
+	case logging.OutputStdout:
+	case logging.OutputFile, logging.OutputBoth:
+		if c.Log.FilePath == "" {
+			errs = append(errs, &FieldError{Key: "LOG_FILE_PATH", Err: errRequired})
+		}
+	default:
+		errs = append(errs, &FieldError{Key: "LOG_OUTPUT", Err: fmt.Errorf("unknown output %q", c.Log.Output)})
+	}
+
+	if c.Log.CloudLogging && c.GoogleCloudProject == "" {
+		errs = append(errs, &FieldError{Key: "GOOGLE_CLOUD_PROJECT", Err: errRequired})
+	}
+
+	switch c.Trace.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		errs = append(errs, &FieldError{Key: "TRACE_EXPORTER", Err: fmt.Errorf("unknown exporter %q", c.Trace.Exporter)})
+	}
+
+	if c.AppEnv.IsCloud() {
+		for _, f := range []struct{ key, val string }{
+			{"DB_URI", c.Database.URI},
+			{"DB_USERNAME", c.Database.Username},
+			{"DB_PASSWORD", c.Database.Password},
+			{"DB_NAME", c.Database.Name},
+			{"AUTH_JWT_SECRET", c.Auth.JWTSecret},
+		} {
+			if f.val == "" {
+				errs = append(errs, &FieldError{Key: f.key, Err: errRequired})
+			}
+		}
+	}
+
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < minJWTSecretLen {
+		errs = append(errs, &FieldError{Key: "AUTH_JWT_SECRET", Err: fmt.Errorf("must be at least %d bytes", minJWTSecretLen)})
+	}
+
+	for _, p := range []struct{ prefix, id, secret, redirect string }{
+		{"AUTH_DISCORD_", c.Auth.DiscordClientID, c.Auth.DiscordClientSecret, c.Auth.DiscordRedirectURL},
+		{"AUTH_GOOGLE_", c.Auth.GoogleClientID, c.Auth.GoogleClientSecret, c.Auth.GoogleRedirectURL},
+	} {
+		if p.id == "" {
+			continue
+		}
+
+		if p.secret == "" {
+			errs = append(errs, &FieldError{Key: p.prefix + "CLIENT_SECRET", Err: errRequired})
+		}
+
+		if p.redirect == "" {
+			errs = append(errs, &FieldError{Key: p.prefix + "REDIRECT_URL", Err: errRequired})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Logging returns the logger configuration.
+func (c *Config) Logging(serviceName string) logging.Config {
+	return logging.Config{
+		Level:              c.Log.Level,
+		Encoding:           c.Log.Encoding,
+		Output:             c.Log.Output,
+		FilePath:           c.Log.FilePath,
+		FileMaxSizeMB:      c.Log.FileMaxSizeMB,
+		FileMaxBackups:     c.Log.FileMaxBackups,
+		FileMaxAgeDays:     c.Log.FileMaxAgeDays,
+		SamplingInitial:    c.Log.SamplingInitial,
+		SamplingThereafter: c.Log.SamplingThereafter,
+		CloudLogging:       c.Log.CloudLogging,
+		CloudProjectID:     c.GoogleCloudProject,
+		ServiceName:        serviceName,
+	}
+}
+
+// Tracing returns the tracer provider configuration.
+func (c *Config) Tracing(serviceName string) tracing.Config {
+	return tracing.Config{
+		ServiceName: serviceName,
+		Exporter:    c.Trace.Exporter,
+		Endpoint:    c.Trace.Endpoint,
+		Insecure:    c.Trace.Insecure,
+	}
+}
+
+// RetryPolicies returns the retry policies by name.
+func (c *Config) RetryPolicies() map[string]retry.Policy {
+	return map[string]retry.Policy{
+		RetryPolicyMongoPing: c.Retry.MongoPing,
+	}
+}
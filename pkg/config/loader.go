@@ -0,0 +1,308 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// struct tags understood by Load.
+const (
+	tagEnv      = "env"
+	tagDefault  = "default"
+	tagRequired = "required"
+	tagSecret   = "secret"
+	tagPrefix   = "prefix"
+)
+
+var (
+	errRequired    = errors.New("required value is missing")
+	errUnknownKey  = errors.New("unknown key")
+	errUnsupported = errors.New("unsupported field type")
+	errNotStruct   = errors.New("destination must be a pointer to a struct")
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+	unmarshaler  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Source resolves configuration keys.
+type Source interface {
+	// Lookup returns the value of key, a description of where it came from, and whether it is set.
+	Lookup(key string) (value string, origin string, ok bool, err error)
+}
+
+// LookupFunc returns the value of a key and whether it is set.
+type LookupFunc func(key string) (string, bool)
+
+// Lookup implements Source.
+func (f LookupFunc) Lookup(key string) (string, string, bool, error) {
+	val, ok := f(key)
+	return val, "env", ok, nil
+}
+
+// FieldError describes why a key could not be loaded.
+type FieldError struct {
+	Key string
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every invalid or missing key, so that all of them are reported at once.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return fmt.Sprintf("invalid configuration (%d errors): %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Entry describes a loaded key and where its value came from.
+type Entry struct {
+	Key    string
+	Value  string
+	Origin string
+	Secret bool
+}
+
+// Load fills dst from config files and environment variables, see NewLayeredSource.
+//
+// Fields are configured with struct tags:
+//
+//	env:"KEY"          the variable name
+//	default:"value"    used when the variable is unset
+//	required:"true"    the variable must be set, or have a default
+//	secret:"true"      the value is masked when printed
+//	reload:"true"      the value is applied on reload, see Watcher
+//	prefix:"DB_"       on a nested struct, prefixes the keys of its fields
+//
+// Supported types are strings, bools, integers, floats, time.Duration, url.URL,
+// slices of those (comma separated) and types implementing encoding.TextUnmarshaler.
+// A non-nil error is of type Errors.
+func Load(dst interface{}) error {
+	_, err := Describe(dst)
+	return err
+}
+
+// Describe fills dst like Load and returns every key with its origin.
+// Entries are returned even when loading fails, to help diagnose the error.
+func Describe(dst interface{}) ([]Entry, error) {
+	src, err := NewLayeredSource(DefaultDir(), os.LookupEnv)
+	if err != nil {
+		return nil, Errors{err}
+	}
+
+	return LoadFrom(dst, src)
+}
+
+// LoadWith fills dst like Load, reading values from lookup only.
+func LoadWith(dst interface{}, lookup LookupFunc) error {
+	_, err := LoadFrom(dst, lookup)
+	return err
+}
+
+// LoadFrom fills dst from src and returns every key with its origin.
+func LoadFrom(dst interface{}, src Source) ([]Entry, error) {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, Errors{errNotStruct}
+	}
+
+	l := &loader{src: src}
+	l.load(v.Elem(), "")
+
+	if c, ok := src.(keyChecker); ok {
+		known := make(map[string]bool, len(l.entries))
+		for _, e := range l.entries {
+			known[e.Key] = true
+		}
+
+		l.errs = append(l.errs, c.UnknownKeys(known)...)
+	}
+
+	if v, ok := dst.(validator); ok {
+		if err := v.Validate(); err != nil {
+			var verrs Errors
+			if errors.As(err, &verrs) {
+				l.errs = append(l.errs, verrs...)
+			} else {
+				l.errs = append(l.errs, err)
+			}
+		}
+	}
+
+	if len(l.errs) > 0 {
+		return l.entries, l.errs
+	}
+
+	return l.entries, nil
+}
+
+// keyChecker is implemented by sources holding keys of their own, such as config files.
+type keyChecker interface {
+	UnknownKeys(known map[string]bool) []error
+}
+
+// validator is implemented by configs with cross-field rules, checked after loading.
+type validator interface {
+	Validate() error
+}
+
+type loader struct {
+	src     Source
+	errs    Errors
+	entries []Entry
+}
+
+func (l *loader) load(v reflect.Value, prefix string) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		if p, ok := f.Tag.Lookup(tagPrefix); ok {
+			l.load(fv, prefix+p)
+			continue
+		}
+
+		name, ok := f.Tag.Lookup(tagEnv)
+		if !ok {
+			continue
+		}
+
+		key := prefix + name
+		secret := f.Tag.Get(tagSecret) == "true"
+
+		val, origin, set, err := l.src.Lookup(key)
+		if err != nil {
+			l.errs = append(l.errs, &FieldError{Key: key, Err: err})
+			continue
+		}
+
+		if !set || val == "" {
+			val, set = f.Tag.Lookup(tagDefault)
+			origin = "default"
+		}
+
+		if !set {
+			l.entries = append(l.entries, Entry{Key: key, Origin: "unset", Secret: secret})
+
+			if f.Tag.Get(tagRequired) == "true" {
+				l.errs = append(l.errs, &FieldError{Key: key, Err: errRequired})
+			}
+
+			continue
+		}
+
+		l.entries = append(l.entries, Entry{Key: key, Value: val, Origin: origin, Secret: secret})
+
+		if err := setValue(fv, val); err != nil {
+			l.errs = append(l.errs, &FieldError{Key: key, Err: err})
+		}
+	}
+}
+
+func setValue(v reflect.Value, val string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(unmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(int64(d))
+
+		return nil
+	case urlType:
+		u, err := url.Parse(val)
+		if err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(*u))
+
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(n)
+	case reflect.Slice:
+		parts := strings.Split(val, ",")
+		s := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+
+		for i, p := range parts {
+			if err := setValue(s.Index(i), strings.TrimSpace(p)); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+
+		v.Set(s)
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), val); err != nil {
+			return err
+		}
+
+		v.Set(p)
+	default:
+		return fmt.Errorf("%s: %w", v.Type(), errUnsupported)
+	}
+
+	return nil
+}
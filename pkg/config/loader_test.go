@@ -0,0 +1,203 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upper is a TextUnmarshaler accepting upper case words only.
+type upper string
+
+func (u *upper) UnmarshalText(text []byte) error {
+	if strings.ToUpper(string(text)) != string(text) {
+		return errors.New("must be upper case")
+	}
+
+	*u = upper(text)
+
+	return nil
+}
+
+type testDB struct {
+	Host     string `env:"HOST" default:"localhost"`
+	Password string `env:"PASSWORD" secret:"true"`
+}
+
+type testConfig struct {
+	Name     string        `env:"NAME" required:"true"`
+	Debug    bool          `env:"DEBUG"`
+	Port     int           `env:"PORT" default:"8080"`
+	Workers  uint8         `env:"WORKERS"`
+	Ratio    float64       `env:"RATIO"`
+	Timeout  time.Duration `env:"TIMEOUT" default:"5s"`
+	Endpoint url.URL       `env:"ENDPOINT"`
+	Tags     []string      `env:"TAGS"`
+	Ports    []int         `env:"PORTS"`
+	Limit    *int          `env:"LIMIT"`
+	Mode     upper         `env:"MODE" default:"FAST"`
+	DB       testDB        `prefix:"DB_"`
+
+	// unexported fields are skipped, even when tagged.
+	hidden  string `env:"HIDDEN"`
+	Ignored string
+}
+
+func vars(m map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		v, ok := m[key]
+		return v, ok
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	limit := 3
+
+	for _, tt := range []struct {
+		name string
+		env  map[string]string
+		want testConfig
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"NAME": "api"},
+			want: testConfig{Name: "api", Port: 8080, Timeout: 5 * time.Second, Mode: "FAST", DB: testDB{Host: "localhost"}},
+		},
+		{
+			name: "empty values fall back to defaults",
+			env:  map[string]string{"NAME": "api", "PORT": "", "TIMEOUT": "", "DB_HOST": ""},
+			want: testConfig{Name: "api", Port: 8080, Timeout: 5 * time.Second, Mode: "FAST", DB: testDB{Host: "localhost"}},
+		},
+		{
+			name: "every type",
+			env: map[string]string{
+				"NAME":        "api",
+				"DEBUG":       "true",
+				"PORT":        "9090",
+				"WORKERS":     "16",
+				"RATIO":       "0.25",
+				"TIMEOUT":     "1m30s",
+				"ENDPOINT":    "https://example.com/v1",
+				"TAGS":        "a, b ,c",
+				"PORTS":       "80,443",
+				"LIMIT":       "3",
+				"MODE":        "SLOW",
+				"DB_HOST":     "db",
+				"DB_PASSWORD": "hunter2",
+				"HIDDEN":      "x",
+			},
+			want: testConfig{
+				Name:     "api",
+				Debug:    true,
+				Port:     9090,
+				Workers:  16,
+				Ratio:    0.25,
+				Timeout:  90 * time.Second,
+				Endpoint: url.URL{Scheme: "https", Host: "example.com", Path: "/v1"},
+				Tags:     []string{"a", "b", "c"},
+				Ports:    []int{80, 443},
+				Limit:    &limit,
+				Mode:     "SLOW",
+				DB:       testDB{Host: "db", Password: "hunter2"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testConfig
+			if _, err := LoadFrom(&got, vars(tt.env)); err != nil {
+				t.Fatalf("LoadFrom() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("LoadFrom() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromEntries(t *testing.T) {
+	var c testConfig
+
+	entries, err := LoadFrom(&c, vars(map[string]string{"NAME": "api", "DB_PASSWORD": "hunter2"}))
+	if err != nil {
+		t.Fatalf("LoadFrom() error = %v", err)
+	}
+
+	byKey := map[string]Entry{}
+	for _, e := range entries {
+		byKey[e.Key] = e
+	}
+
+	for key, want := range map[string]Entry{
+		"NAME":        {Key: "NAME", Value: "api", Origin: "env"},
+		"PORT":        {Key: "PORT", Value: "8080", Origin: "default"},
+		"DEBUG":       {Key: "DEBUG", Origin: "unset"},
+		"DB_PASSWORD": {Key: "DB_PASSWORD", Value: "hunter2", Origin: "env", Secret: true},
+	} {
+		if got := byKey[key]; got != want {
+			t.Errorf("entry %s = %+v, want %+v", key, got, want)
+		}
+	}
+
+	if _, ok := byKey["Ignored"]; ok {
+		t.Error("a field without env tag was loaded")
+	}
+}
+
+func TestLoadFromCollectsErrors(t *testing.T) {
+	var c testConfig
+
+	_, err := LoadFrom(&c, vars(map[string]string{
+		"DEBUG":   "maybe",
+		"PORT":    "http",
+		"WORKERS": "256",
+		"TIMEOUT": "5",
+		"PORTS":   "80,x",
+		"MODE":    "slow",
+	}))
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("LoadFrom() error = %v, want Errors", err)
+	}
+
+	keys := []string{}
+
+	for _, e := range errs {
+		var fe *FieldError
+		if !errors.As(e, &fe) {
+			t.Fatalf("error %v is not a FieldError", e)
+		}
+
+		keys = append(keys, fe.Key)
+	}
+
+	if want := []string{"NAME", "DEBUG", "PORT", "WORKERS", "TIMEOUT", "PORTS", "MODE"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("invalid keys = %v, want %v", keys, want)
+	}
+
+	if !errors.Is(errs[0], errRequired) {
+		t.Fatalf("NAME error = %v, want %v", errs[0], errRequired)
+	}
+}
+
+func TestLoadFromRejects(t *testing.T) {
+	var notStruct int
+	if _, err := LoadFrom(&notStruct, vars(nil)); err == nil {
+		t.Fatal("LoadFrom() of a non-struct succeeded")
+	}
+
+	var unsupported struct {
+		M map[string]string `env:"M"`
+	}
+
+	_, err := LoadFrom(&unsupported, vars(map[string]string{"M": "a"}))
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || !errors.Is(errs[0], errUnsupported) {
+		t.Fatalf("LoadFrom() error = %v, want %v", err, errUnsupported)
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const masked = "********"
+
+// Print writes the effective configuration of dst with the origin of each value.
+// Secret values are masked. The loading error, if any, is returned after printing.
+func Print(w io.Writer, dst interface{}) error {
+	entries, loadErr := Describe(dst)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+
+	for _, e := range entries {
+		val := e.Value
+		if e.Secret && val != "" {
+			val = masked
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Key, val, e.Origin)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to print config: %w", err)
+	}
+
+	return loadErr
+}
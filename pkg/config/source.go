@@ -0,0 +1,221 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// envConfigDir names the directory holding the config files.
+	envConfigDir = "CONFIG_DIR"
+
+	defaultConfigDir = "config"
+
+	// fileSuffix marks variables holding the path of a file with the value, e.g. DB_PASSWORD_FILE.
+	fileSuffix = "_FILE"
+
+	baseFileName = "base"
+)
+
+// extensions are tried in order for each config file.
+var extensions = []string{".yaml", ".yml", ".toml"}
+
+// DefaultDir returns the config directory, taken from CONFIG_DIR.
+func DefaultDir() string {
+	if dir := os.Getenv(envConfigDir); dir != "" {
+		return dir
+	}
+
+	return defaultConfigDir
+}
+
+type fileLayer struct {
+	path   string
+	values map[string]string
+}
+
+// LayeredSource resolves keys from, in order of precedence:
+//  1. the environment variable KEY
+//  2. the file named by the environment variable KEY_FILE, for mounted secrets
+//  3. the config file of the application environment, e.g. config/prd.yaml
+//  4. the base config file, config/base.yaml
+//
+// Nested keys in files are joined with underscores and upper-cased,
+// so log: {level: debug} sets LOG_LEVEL.
+type LayeredSource struct {
+	lookupEnv LookupFunc
+	layers    []fileLayer
+}
+
+// NewLayeredSource reads the config files in dir. Missing files are skipped.
+// The environment file is chosen by APP_ENV, from the environment or the base file.
+func NewLayeredSource(dir string, lookupEnv LookupFunc) (*LayeredSource, error) {
+	s := &LayeredSource{lookupEnv: lookupEnv}
+
+	base, err := readLayer(dir, baseFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if base != nil {
+		s.layers = append(s.layers, *base)
+	}
+
+	appEnv, _ := lookupEnv("APP_ENV")
+	if appEnv == "" && base != nil {
+		appEnv = base.values["APP_ENV"]
+	}
+
+	if appEnv != "" {
+		overlay, err := readLayer(dir, appEnv)
+		if err != nil {
+			return nil, err
+		}
+
+		if overlay != nil {
+			s.layers = append(s.layers, *overlay)
+		}
+	}
+
+	return s, nil
+}
+
+// Files returns the paths of the config files read, lowest precedence first.
+func (s *LayeredSource) Files() []string {
+	files := make([]string, 0, len(s.layers))
+	for _, l := range s.layers {
+		files = append(files, l.path)
+	}
+
+	return files
+}
+
+// UnknownKeys returns an error for every key of the config files that is not in known,
+// so that misspelled keys are reported instead of silently ignored.
+func (s *LayeredSource) UnknownKeys(known map[string]bool) []error {
+	var errs []error
+
+	for _, l := range s.layers {
+		keys := make([]string, 0, len(l.values))
+		for key := range l.values {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if !known[key] {
+				errs = append(errs, &FieldError{Key: key, Err: fmt.Errorf("%w in %s", errUnknownKey, l.path)})
+			}
+		}
+	}
+
+	return errs
+}
+
+// Lookup implements Source.
+func (s *LayeredSource) Lookup(key string) (string, string, bool, error) {
+	if val, ok := s.lookupEnv(key); ok && val != "" {
+		return val, "env " + key, true, nil
+	}
+
+	if path, ok := s.lookupEnv(key + fileSuffix); ok && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", false, fmt.Errorf("failed to read %s%s: %w", key, fileSuffix, err)
+		}
+
+		return strings.TrimSpace(string(b)), "file " + path + " (" + key + fileSuffix + ")", true, nil
+	}
+
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if val, ok := s.layers[i].values[key]; ok {
+			return val, "file " + s.layers[i].path, true, nil
+		}
+	}
+
+	return "", "", false, nil
+}
+
+func readLayer(dir string, name string) (*fileLayer, error) {
+	for _, ext := range extensions {
+		path := filepath.Join(dir, name+ext)
+
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+
+		raw := map[string]interface{}{}
+		if ext == ".toml" {
+			err = toml.Unmarshal(b, &raw)
+		} else {
+			err = yaml.Unmarshal(b, &raw)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+
+		values := map[string]string{}
+		flatten("", raw, values)
+
+		return &fileLayer{path: path, values: values}, nil
+	}
+
+	return nil, nil
+}
+
+// flatten turns nested maps into upper-cased, underscore-joined keys.
+func flatten(prefix string, raw map[string]interface{}, out map[string]string) {
+	keys := make([]string, 0, len(raw))
+	for k := range raw {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		key := strings.ToUpper(prefix + k)
+
+		switch v := raw[k].(type) {
+		case map[string]interface{}:
+			flatten(key+"_", v, out)
+		case []interface{}:
+			parts := make([]string, 0, len(v))
+			for _, e := range v {
+				parts = append(parts, formatScalar(e))
+			}
+
+			out[key] = strings.Join(parts, ",")
+		case nil:
+		default:
+			out[key] = formatScalar(v)
+		}
+	}
+}
+
+// formatScalar formats a decoded value the way it would be written in an environment variable.
+// Floats are written without exponent, so that 1e6 still loads into an integer field.
+func formatScalar(v interface{}) string {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files, by name, into a temporary directory it returns.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestLayeredSourcePrecedence(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": "app_env: dev\na: base\nb: base\nc: base\nd: base\ne: base\n",
+		"dev.toml":  "b = \"dev\"\nc = \"dev\"\nd = \"dev\"\ne = \"dev\"\n",
+		"secret":    "from secret file\n",
+	})
+
+	src, err := NewLayeredSource(dir, vars(map[string]string{
+		"C_FILE": filepath.Join(dir, "secret"),
+		"D_FILE": filepath.Join(dir, "secret"),
+		"D":      "env",
+		// an empty variable does not hide the files.
+		"E": "",
+	}))
+	if err != nil {
+		t.Fatalf("NewLayeredSource() error = %v", err)
+	}
+
+	if want := []string{filepath.Join(dir, "base.yaml"), filepath.Join(dir, "dev.toml")}; !reflect.DeepEqual(src.Files(), want) {
+		t.Fatalf("Files() = %v, want %v", src.Files(), want)
+	}
+
+	for _, tt := range []struct {
+		key, want, origin string
+	}{
+		{key: "A", want: "base", origin: "file " + filepath.Join(dir, "base.yaml")},
+		{key: "B", want: "dev", origin: "file " + filepath.Join(dir, "dev.toml")},
+		{key: "C", want: "from secret file", origin: "file " + filepath.Join(dir, "secret") + " (C_FILE)"},
+		{key: "D", want: "env", origin: "env D"},
+		{key: "E", want: "dev", origin: "file " + filepath.Join(dir, "dev.toml")},
+	} {
+		val, origin, ok, err := src.Lookup(tt.key)
+		if err != nil || !ok || val != tt.want || origin != tt.origin {
+			t.Errorf("Lookup(%s) = %q, %q, %v, %v, want %q from %q", tt.key, val, origin, ok, err, tt.want, tt.origin)
+		}
+	}
+
+	if _, _, ok, err := src.Lookup("MISSING"); ok || err != nil {
+		t.Errorf("Lookup(MISSING) = %v, %v, want unset", ok, err)
+	}
+}
+
+func TestLayeredSourceEnvironmentSelectsFile(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml": "app_env: dev\nname: base\n",
+		"dev.yaml":  "name: dev\n",
+		"prd.yml":   "name: prd\n",
+	})
+
+	src, err := NewLayeredSource(dir, vars(map[string]string{"APP_ENV": "prd"}))
+	if err != nil {
+		t.Fatalf("NewLayeredSource() error = %v", err)
+	}
+
+	if val, _, _, _ := src.Lookup("NAME"); val != "prd" {
+		t.Fatalf("NAME = %q, want the APP_ENV variable to select prd.yml", val)
+	}
+}
+
+func TestLayeredSourceParsesFiles(t *testing.T) {
+	for _, tt := range []struct {
+		name, file, content string
+	}{
+		{
+			name:    "yaml",
+			file:    "base.yaml",
+			content: "log:\n  level: debug\n  cloud: true\nserver:\n  hosts: [a, b]\n  max: 1e6\n  ratio: 0.5\n  empty:\n",
+		},
+		{
+			name:    "toml",
+			file:    "base.toml",
+			content: "[log]\nlevel = \"debug\"\ncloud = true\n\n[server]\nhosts = [\"a\", \"b\"]\nmax = 1e6\nratio = 0.5\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := NewLayeredSource(writeFiles(t, map[string]string{tt.file: tt.content}), vars(nil))
+			if err != nil {
+				t.Fatalf("NewLayeredSource() error = %v", err)
+			}
+
+			for key, want := range map[string]string{
+				"LOG_LEVEL":    "debug",
+				"LOG_CLOUD":    "true",
+				"SERVER_HOSTS": "a,b",
+				"SERVER_MAX":   "1000000",
+				"SERVER_RATIO": "0.5",
+			} {
+				if val, _, _, _ := src.Lookup(key); val != want {
+					t.Errorf("Lookup(%s) = %q, want %q", key, val, want)
+				}
+			}
+
+			if _, _, ok, _ := src.Lookup("SERVER_EMPTY"); ok {
+				t.Error("a null value was set")
+			}
+		})
+	}
+}
+
+func TestLayeredSourceErrors(t *testing.T) {
+	if _, err := NewLayeredSource(writeFiles(t, map[string]string{"base.yaml": "a: [\n"}), vars(nil)); err == nil {
+		t.Error("NewLayeredSource() of an invalid file succeeded")
+	}
+
+	src, err := NewLayeredSource(t.TempDir(), vars(map[string]string{"A_FILE": "/nonexistent/secret"}))
+	if err != nil {
+		t.Fatalf("NewLayeredSource() of an empty directory error = %v", err)
+	}
+
+	if _, _, _, err := src.Lookup("A"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Lookup() error = %v, want the missing secret file", err)
+	}
+}
+
+func TestLoadFromRejectsUnknownFileKeys(t *testing.T) {
+	var c struct {
+		Log struct {
+			Level string `env:"LEVEL"`
+		} `prefix:"LOG_"`
+	}
+
+	dir := writeFiles(t, map[string]string{"base.yaml": "log:\n  level: debug\n  levle: info\n"})
+
+	src, err := NewLayeredSource(dir, vars(nil))
+	if err != nil {
+		t.Fatalf("NewLayeredSource() error = %v", err)
+	}
+
+	_, err = LoadFrom(&c, src)
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("LoadFrom() error = %v, want one unknown key", err)
+	}
+
+	var fe *FieldError
+	if !errors.As(errs[0], &fe) || fe.Key != "LOG_LEVLE" || !errors.Is(fe, errUnknownKey) || !strings.Contains(fe.Error(), "base.yaml") {
+		t.Fatalf("error = %v, want LOG_LEVLE unknown in base.yaml", errs[0])
+	}
+
+	if c.Log.Level != "debug" {
+		t.Fatalf("LOG_LEVEL = %q, want the known key loaded", c.Log.Level)
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"go.uber.org/zap"
+)
+
+// tagReload marks keys whose new value is applied on reload without a restart.
+const tagReload = "reload"
+
+// debounce coalesces the bursts of events editors and Kubernetes volume updates produce.
+const debounce = 200 * time.Millisecond
+
+// Watcher reloads the configuration when a config file changes or on SIGHUP.
+// Only keys tagged reload:"true" are applied; changes to other keys are logged and ignored.
+type Watcher struct {
+	logger logging.Logger
+
+	current atomic.Value // *Config
+
+	// reload serializes reloads, so that they apply in the order they loaded the config.
+	reload sync.Mutex
+
+	mu          sync.Mutex
+	entries     []Entry
+	subscribers []func(prev, next *Config)
+
+	fsw    *fsnotify.Watcher
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWatcher returns a watcher holding the initial configuration and its entries, as returned by Describe.
+func NewWatcher(initial *Config, entries []Entry, logger logging.Logger) *Watcher {
+	w := &Watcher{logger: logger, entries: entries}
+	w.current.Store(initial)
+
+	return w
+}
+
+// Current returns the current configuration. It must not be modified.
+func (w *Watcher) Current() *Config {
+	return w.current.Load().(*Config)
+}
+
+// Subscribe registers fn to be called with the previous and the new configuration after
+// each applied reload, so that it can act on the keys that changed only.
+func (w *Watcher) Subscribe(fn func(prev, next *Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start watches the config directory and SIGHUP until Close is called.
+func (w *Watcher) Start() error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to init file watcher: %w", err)
+	}
+
+	// watch the directory: files are often replaced rather than written in place.
+	if err := fsw.Add(DefaultDir()); err != nil && !os.IsNotExist(err) {
+		_ = fsw.Close()
+		return fmt.Errorf("failed to watch %s: %w", DefaultDir(), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.fsw = fsw
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(w.done)
+		defer signal.Stop(hup)
+
+		var timer <-chan time.Time
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				w.logger.Info("received SIGHUP, reloading config")
+				_ = w.Reload()
+			case ev, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+
+				if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+					timer = time.After(debounce)
+				}
+			case <-timer:
+				timer = nil
+				w.logger.Info("config file changed, reloading config")
+				_ = w.Reload()
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+
+				w.logger.Warn("config file watcher error", zap.Error(err))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	if w.cancel == nil {
+		return nil
+	}
+
+	w.cancel()
+	<-w.done
+
+	return w.fsw.Close()
+}
+
+// Reload loads and validates the configuration, and applies the changed reloadable keys.
+// An invalid configuration is logged and nothing is applied. Subscribers must not call Reload.
+func (w *Watcher) Reload() error {
+	w.reload.Lock()
+	defer w.reload.Unlock()
+
+	next := new(Config)
+
+	entries, err := Describe(next)
+	if err != nil {
+		w.logger.Error("invalid config, keeping the current one", zap.Error(err))
+		return err
+	}
+
+	w.mu.Lock()
+
+	reloadable := reloadableKeys(reflect.TypeOf(Config{}), "")
+	previous := indexEntries(w.entries)
+
+	var applied, rejected []string
+
+	for i, e := range entries {
+		old, ok := previous[e.Key]
+		if ok && old.Value == e.Value {
+			continue
+		}
+
+		if !reloadable[e.Key] {
+			rejected = append(rejected, e.Key)
+			// keep reporting the running value until a restart applies the new one.
+			entries[i] = old
+
+			continue
+		}
+
+		applied = append(applied, fmt.Sprintf("%s: %s -> %s", e.Key, display(old), display(e)))
+	}
+
+	if len(rejected) > 0 {
+		w.logger.Warn("config keys changed but cannot be reloaded, restart to apply", zap.Strings("keys", rejected))
+	}
+
+	if len(applied) == 0 {
+		w.mu.Unlock()
+		return nil
+	}
+
+	prev := w.Current()
+	merged := *prev
+	mergeReloadable(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(next).Elem())
+
+	w.entries = entries
+	w.current.Store(&merged)
+
+	// subscribers are called without the lock, so that they may use the watcher.
+	subscribers := make([]func(prev, next *Config), len(w.subscribers))
+	copy(subscribers, w.subscribers)
+	w.mu.Unlock()
+
+	w.logger.Info("config reloaded", zap.Strings("changes", applied))
+
+	for _, fn := range subscribers {
+		fn(prev, &merged)
+	}
+
+	return nil
+}
+
+func indexEntries(entries []Entry) map[string]Entry {
+	m := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		m[e.Key] = e
+	}
+
+	return m
+}
+
+func display(e Entry) string {
+	if e.Secret && e.Value != "" {
+		return masked
+	}
+
+	return fmt.Sprintf("%q", e.Value)
+}
+
+// reloadableKeys returns the keys of fields tagged reload:"true".
+func reloadableKeys(t reflect.Type, prefix string) map[string]bool {
+	keys := map[string]bool{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if p, ok := f.Tag.Lookup(tagPrefix); ok {
+			for k := range reloadableKeys(f.Type, prefix+p) {
+				keys[k] = true
+			}
+
+			continue
+		}
+
+		if name, ok := f.Tag.Lookup(tagEnv); ok && f.Tag.Get(tagReload) == "true" {
+			keys[prefix+name] = true
+		}
+	}
+
+	return keys
+}
+
+// mergeReloadable copies the fields tagged reload:"true" from next into cur.
+func mergeReloadable(cur reflect.Value, next reflect.Value) {
+	t := cur.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if _, ok := f.Tag.Lookup(tagPrefix); ok {
+			mergeReloadable(cur.Field(i), next.Field(i))
+			continue
+		}
+
+		if f.Tag.Get(tagReload) == "true" {
+			cur.Field(i).Set(next.Field(i))
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type change struct {
+	prev, next *Config
+}
+
+// newTestWatcher loads the config from base.yaml in a temporary CONFIG_DIR, and returns a
+// watcher of it reporting applied reloads on the returned channel.
+func newTestWatcher(t *testing.T, base string) (*Watcher, string, <-chan change, *observer.ObservedLogs) {
+	t.Helper()
+
+	dir := writeFiles(t, map[string]string{"base.yaml": base})
+	t.Setenv(envConfigDir, dir)
+
+	initial := new(Config)
+
+	entries, err := Describe(initial)
+	if err != nil {
+		t.Fatalf("Describe() error = %v", err)
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	logger, err := logging.NewLogger(logging.Config{Level: "debug"}, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return core
+	}))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+
+	changes := make(chan change, 10)
+
+	w := NewWatcher(initial, entries, logger)
+	w.Subscribe(func(prev, next *Config) {
+		changes <- change{prev: prev, next: next}
+	})
+
+	return w, filepath.Join(dir, "base.yaml"), changes, logs
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func waitChange(t *testing.T, changes <-chan change) change {
+	t.Helper()
+
+	select {
+	case c := <-changes:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatal("the config was not reloaded")
+		return change{}
+	}
+}
+
+func noChange(t *testing.T, changes <-chan change) {
+	t.Helper()
+
+	select {
+	case c := <-changes:
+		t.Fatalf("unexpected reload from %+v to %+v", c.prev.Log, c.next.Log)
+	default:
+	}
+}
+
+func TestWatcherReloadAppliesReloadableKeys(t *testing.T) {
+	w, path, changes, logs := newTestWatcher(t, "log:\n  level: info\nserver:\n  addr: ':8080'\n")
+
+	writeFile(t, path, "log:\n  level: debug\nserver:\n  addr: ':9999'\n")
+
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	c := waitChange(t, changes)
+	if c.prev.Log.Level != "info" || c.next.Log.Level != "debug" {
+		t.Fatalf("reload = %s -> %s, want info -> debug", c.prev.Log.Level, c.next.Log.Level)
+	}
+
+	if cur := w.Current(); cur != c.next || cur.Server.Addr != ":8080" {
+		t.Fatalf("current = %+v, want the new level and the running address", cur.Server)
+	}
+
+	rejected := logs.FilterMessage("config keys changed but cannot be reloaded, restart to apply").All()
+	if len(rejected) != 1 || !strings.Contains(rejected[0].ContextMap()["keys"].([]interface{})[0].(string), "SERVER_ADDR") {
+		t.Fatalf("rejected = %v, want SERVER_ADDR", rejected)
+	}
+
+	// the rejected key keeps its running value, so reloading again changes nothing.
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	noChange(t, changes)
+}
+
+func TestWatcherReloadIgnoresNonReloadableChanges(t *testing.T) {
+	w, path, changes, _ := newTestWatcher(t, "server:\n  addr: ':8080'\n")
+	initial := w.Current()
+
+	writeFile(t, path, "server:\n  addr: ':9999'\n")
+
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	noChange(t, changes)
+
+	if w.Current() != initial {
+		t.Fatal("the config was replaced without reloadable change")
+	}
+}
+
+func TestWatcherReloadRejectsInvalidConfig(t *testing.T) {
+	w, path, changes, logs := newTestWatcher(t, "log:\n  level: info\n")
+	initial := w.Current()
+
+	for _, content := range []string{
+		"log:\n  level: verbose\n",
+		"log:\n  levle: debug\n",
+		"log: [\n",
+	} {
+		writeFile(t, path, content)
+
+		if err := w.Reload(); err == nil {
+			t.Errorf("Reload() of %q succeeded", content)
+		}
+	}
+
+	noChange(t, changes)
+
+	if w.Current() != initial {
+		t.Fatal("an invalid config was applied")
+	}
+
+	if n := logs.FilterMessage("invalid config, keeping the current one").Len(); n != 3 {
+		t.Fatalf("logged %d invalid configs, want 3", n)
+	}
+}
+
+func TestWatcherConcurrentReloads(t *testing.T) {
+	w, path, changes, _ := newTestWatcher(t, "log:\n  level: info\n")
+
+	writeFile(t, path, "log:\n  level: debug\n")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			_ = w.Reload()
+		}()
+	}
+
+	wg.Wait()
+
+	waitChange(t, changes)
+	noChange(t, changes)
+}
+
+func TestWatcherReloadsOnFileChange(t *testing.T) {
+	w, path, changes, _ := newTestWatcher(t, "log:\n  level: info\n")
+
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = w.Close() })
+
+	writeFile(t, path, "log:\n  level: warn\n")
+
+	if c := waitChange(t, changes); c.next.Log.Level != "warn" {
+		t.Fatalf("level = %s, want warn", c.next.Log.Level)
+	}
+}
+
+func TestWatcherReloadsOnSIGHUP(t *testing.T) {
+	w, _, changes, _ := newTestWatcher(t, "log:\n  level: info\n")
+
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	t.Cleanup(func() { _ = w.Close() })
+
+	// the environment does not trigger the file watcher, only the signal reloads it.
+	t.Setenv("LOG_LEVEL", "error")
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Kill() error = %v", err)
+	}
+
+	if c := waitChange(t, changes); c.next.Log.Level != "error" {
+		t.Fatalf("level = %s, want error", c.next.Log.Level)
+	}
+}
+
+func TestPrintMasksSecrets(t *testing.T) {
+	t.Setenv(envConfigDir, t.TempDir())
+	t.Setenv("AUTH_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+	t.Setenv("ADMIN_TOKEN", "")
+
+	var buf bytes.Buffer
+	if err := Print(&buf, new(Config)); err != nil {
+		t.Fatalf("Print() error = %v", err)
+	}
+
+	out := buf.String()
+
+	if strings.Contains(out, "0123456789abcdef") {
+		t.Fatalf("Print() leaked a secret:\n%s", out)
+	}
+
+	rows := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows[fields[0]] = strings.Join(fields[1:], " ")
+		}
+	}
+
+	for key, want := range map[string]string{
+		"AUTH_JWT_SECRET": masked + " env AUTH_JWT_SECRET",
+		"LOG_LEVEL":       "info default",
+		// an unset secret is printed empty, to tell it apart from a set one.
+		"ADMIN_TOKEN": "unset",
+	} {
+		if rows[key] != want {
+			t.Errorf("%s row = %q, want %q", key, rows[key], want)
+		}
+	}
+}
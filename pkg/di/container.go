@@ -0,0 +1,241 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/metrics"
+)
+
+// Key identifies a component in a Container.
+type Key string
+
+// Hook is a lifecycle hook of a component.
+type Hook func(ctx context.Context) error
+
+// Constructor builds a component, resolving its dependencies through r.
+type Constructor func(r Resolver) (interface{}, error)
+
+// Resolver is passed to constructors to resolve dependencies and register lifecycle hooks.
+type Resolver interface {
+	// Get returns the dependency registered under key, building it if needed.
+	Get(key Key) (interface{}, error)
+	// OnStart registers a hook run by Container.Start after the hooks of the component's dependencies.
+	OnStart(hook Hook)
+	// OnStop registers a hook run by Container.Stop before the hooks of the component's dependencies.
+	OnStop(hook Hook)
+}
+
+var (
+	errNotRegistered = errors.New("component is not registered")
+	errCycle         = errors.New("dependency cycle")
+	errAlreadyBuilt  = errors.New("component is already built")
+)
+
+type component struct {
+	key         Key
+	constructor Constructor
+
+	once  sync.Once
+	value interface{}
+	err   error
+
+	onStart []Hook
+	onStop  []Hook
+
+	// started is set once the OnStart hooks succeeded, guarded by Container.mu.
+	started bool
+}
+
+// Container builds components lazily, once, and runs their lifecycle hooks in dependency order.
+type Container struct {
+	mu         sync.Mutex
+	components map[Key]*component
+
+	// order lists built components, dependencies first.
+	order []*component
+}
+
+// NewContainer returns an empty container.
+func NewContainer() *Container {
+	return &Container{components: map[Key]*component{}}
+}
+
+// Register registers the constructor of key. Registering a key twice replaces the constructor,
+// which lets tests swap components, as long as the component has not been built yet.
+func (c *Container) Register(key Key, constructor Constructor) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.components[key]; ok && c.isBuilt(old) {
+		return fmt.Errorf("%s: %w", key, errAlreadyBuilt)
+	}
+
+	c.components[key] = &component{key: key, constructor: constructor}
+
+	return nil
+}
+
+// Replace registers a prebuilt value under key, e.g. a fake in tests.
+func (c *Container) Replace(key Key, value interface{}) error {
+	return c.Register(key, func(Resolver) (interface{}, error) {
+		return value, nil
+	})
+}
+
+// Get returns the component registered under key, building it and its dependencies if needed.
+func (c *Container) Get(key Key) (interface{}, error) {
+	return c.get(key, nil)
+}
+
+// MustGet is like Get but panics if the component cannot be built.
+func (c *Container) MustGet(key Key) interface{} {
+	v, err := c.Get(key)
+	if err != nil {
+		panic(fmt.Sprintf("%+v", err))
+	}
+
+	return v
+}
+
+func (c *Container) get(key Key, path []Key) (interface{}, error) {
+	for _, k := range path {
+		if k == key {
+			return nil, fmt.Errorf("%s: %w", formatPath(append(path, key)), errCycle)
+		}
+	}
+
+	c.mu.Lock()
+	comp, ok := c.components[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, errNotRegistered)
+	}
+
+	comp.once.Do(func() {
+		r := &resolver{container: c, component: comp, path: append(append([]Key{}, path...), key)}
+
+		comp.value, comp.err = comp.constructor(r)
+		if comp.err != nil {
+			comp.err = fmt.Errorf("failed to build %s: %w", key, comp.err)
+			return
+		}
+
+		c.mu.Lock()
+		c.order = append(c.order, comp)
+		c.mu.Unlock()
+	})
+
+	return comp.value, comp.err
+}
+
+// isBuilt must be called with c.mu held.
+func (c *Container) isBuilt(comp *component) bool {
+	for _, o := range c.order {
+		if o == comp {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Start runs the OnStart hooks of built components that are not started yet, dependencies first.
+// It stops at the first failing hook; Stop then stops the components started so far.
+func (c *Container) Start(ctx context.Context) error {
+	for _, comp := range c.built() {
+		if c.isStarted(comp) {
+			continue
+		}
+
+		for _, h := range comp.onStart {
+			if err := h(ctx); err != nil {
+				return fmt.Errorf("failed to start %s: %w", comp.key, err)
+			}
+		}
+
+		c.mu.Lock()
+		comp.started = true
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Stop runs the OnStop hooks of started components in reverse dependency order.
+// Every hook runs; the errors are joined.
+func (c *Container) Stop(ctx context.Context) error {
+	built := c.built()
+	msgs := []string{}
+
+	for i := len(built) - 1; i >= 0; i-- {
+		comp := built[i]
+		if !c.isStarted(comp) {
+			continue
+		}
+
+		for j := len(comp.onStop) - 1; j >= 0; j-- {
+			err := comp.onStop[j](ctx)
+			metrics.ObserveClose(string(comp.key), err)
+
+			if err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s: %v", comp.key, err))
+			}
+		}
+
+		c.mu.Lock()
+		comp.started = false
+		c.mu.Unlock()
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("failed to stop components: %s", strings.Join(msgs, "; "))
+	}
+
+	return nil
+}
+
+func (c *Container) isStarted(comp *component) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return comp.started
+}
+
+func (c *Container) built() []*component {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*component{}, c.order...)
+}
+
+type resolver struct {
+	container *Container
+	component *component
+	path      []Key
+}
+
+func (r *resolver) Get(key Key) (interface{}, error) {
+	return r.container.get(key, r.path)
+}
+
+func (r *resolver) OnStart(hook Hook) {
+	r.component.onStart = append(r.component.onStart, hook)
+}
+
+func (r *resolver) OnStop(hook Hook) {
+	r.component.onStop = append(r.component.onStop, hook)
+}
+
+func formatPath(path []Key) string {
+	parts := make([]string, 0, len(path))
+	for _, k := range path {
+		parts = append(parts, string(k))
+	}
+
+	return strings.Join(parts, " -> ")
+}
@@ -0,0 +1,223 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errHook = errors.New("hook failed")
+
+// recorder registers components whose hooks append "start <key>" and "stop <key>" to events.
+type recorder struct {
+	events []string
+}
+
+// component returns a constructor building key after deps, failing its start hook if failStart.
+func (rec *recorder) component(key Key, failStart bool, deps ...Key) Constructor {
+	return func(r Resolver) (interface{}, error) {
+		for _, d := range deps {
+			if _, err := r.Get(d); err != nil {
+				return nil, err
+			}
+		}
+
+		rec.events = append(rec.events, "build "+string(key))
+
+		r.OnStart(func(context.Context) error {
+			if failStart {
+				return errHook
+			}
+
+			rec.events = append(rec.events, "start "+string(key))
+
+			return nil
+		})
+		r.OnStop(func(context.Context) error {
+			rec.events = append(rec.events, "stop "+string(key))
+			return nil
+		})
+
+		return string(key), nil
+	}
+}
+
+func mustRegister(t *testing.T, c *Container, key Key, constructor Constructor) {
+	t.Helper()
+
+	if err := c.Register(key, constructor); err != nil {
+		t.Fatalf("Register(%s) error = %v", key, err)
+	}
+}
+
+func wantEvents(t *testing.T, rec *recorder, want ...string) {
+	t.Helper()
+
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Fatalf("events = %v, want %v", rec.events, want)
+	}
+
+	rec.events = nil
+}
+
+func TestContainerBuildsLazilyOnce(t *testing.T) {
+	c := NewContainer()
+	rec := &recorder{}
+
+	mustRegister(t, c, "db", rec.component("db", false))
+	mustRegister(t, c, "unused", rec.component("unused", false))
+	wantEvents(t, rec)
+
+	for i := 0; i < 2; i++ {
+		v, err := c.Get("db")
+		if err != nil || v != "db" {
+			t.Fatalf("Get() = %v, %v, want db", v, err)
+		}
+	}
+
+	wantEvents(t, rec, "build db")
+
+	// unused was never built, so its hooks never run.
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	wantEvents(t, rec, "start db")
+}
+
+func TestContainerStartStopOrder(t *testing.T) {
+	c := NewContainer()
+	rec := &recorder{}
+
+	mustRegister(t, c, "config", rec.component("config", false))
+	mustRegister(t, c, "db", rec.component("db", false, "config"))
+	mustRegister(t, c, "server", rec.component("server", false, "db", "config"))
+
+	if _, err := c.Get("server"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	wantEvents(t, rec, "build config", "build db", "build server")
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	wantEvents(t, rec, "start config", "start db", "start server")
+
+	// starting again does not restart started components.
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	wantEvents(t, rec)
+
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	wantEvents(t, rec, "stop server", "stop db", "stop config")
+}
+
+func TestContainerStopsStartedComponentsOnFailure(t *testing.T) {
+	c := NewContainer()
+	rec := &recorder{}
+
+	mustRegister(t, c, "config", rec.component("config", false))
+	mustRegister(t, c, "db", rec.component("db", false, "config"))
+	mustRegister(t, c, "server", rec.component("server", true, "db"))
+
+	if _, err := c.Get("server"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	rec.events = nil
+
+	if err := c.Start(context.Background()); !errors.Is(err, errHook) {
+		t.Fatalf("Start() error = %v, want %v", err, errHook)
+	}
+
+	wantEvents(t, rec, "start config", "start db")
+
+	// the server did not start, so it is not stopped.
+	if err := c.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	wantEvents(t, rec, "stop db", "stop config")
+}
+
+func TestContainerStopJoinsErrors(t *testing.T) {
+	c := NewContainer()
+
+	for _, key := range []Key{"a", "b"} {
+		mustRegister(t, c, key, func(r Resolver) (interface{}, error) {
+			r.OnStop(func(context.Context) error { return errHook })
+			return nil, nil
+		})
+
+		if _, err := c.Get(key); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	err := c.Stop(context.Background())
+	if want := "failed to stop components: b: hook failed; a: hook failed"; err == nil || err.Error() != want {
+		t.Fatalf("Stop() error = %v, want %q", err, want)
+	}
+}
+
+func TestContainerOverride(t *testing.T) {
+	c := NewContainer()
+	rec := &recorder{}
+
+	mustRegister(t, c, "db", rec.component("db", false))
+	mustRegister(t, c, "server", rec.component("server", false, "db"))
+
+	// a test swaps the database before anything is built.
+	if err := c.Replace("db", "fake db"); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	if _, err := c.Get("server"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+
+	if v := c.MustGet("db"); v != "fake db" {
+		t.Fatalf("db = %v, want the fake", v)
+	}
+
+	wantEvents(t, rec, "build server")
+
+	if err := c.Replace("db", "late fake"); !errors.Is(err, errAlreadyBuilt) {
+		t.Fatalf("Replace() of a built component error = %v, want %v", err, errAlreadyBuilt)
+	}
+}
+
+func TestContainerErrors(t *testing.T) {
+	c := NewContainer()
+
+	if _, err := c.Get("missing"); !errors.Is(err, errNotRegistered) {
+		t.Fatalf("Get() error = %v, want %v", err, errNotRegistered)
+	}
+
+	mustRegister(t, c, "a", func(r Resolver) (interface{}, error) { return r.Get("b") })
+	mustRegister(t, c, "b", func(r Resolver) (interface{}, error) { return r.Get("a") })
+
+	if _, err := c.Get("a"); !errors.Is(err, errCycle) {
+		t.Fatalf("Get() error = %v, want %v", err, errCycle)
+	}
+
+	mustRegister(t, c, "broken", func(Resolver) (interface{}, error) { return nil, errHook })
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get("broken"); !errors.Is(err, errHook) {
+			t.Fatalf("Get() error = %v, want the constructor error", err)
+		}
+	}
+}
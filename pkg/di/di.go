@@ -1,37 +1,94 @@
 package di
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"sync/atomic"
 
+	cfg "github.com/puipuipartpicker/kbpartpicker/api/pkg/config"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/env"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/health"
 	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/tracing"
+	"go.uber.org/zap"
 )
 
+const serviceName = "kbpartpicker-api"
+
+// keys of the components registered by this package.
 const (
-	envAppEnv                              env.VarName = "APP_ENV"
-	envLogLevel                            env.VarName = "LOG_LEVEL"
+	KeyConfig        Key = "config"
+	KeyConfigWatcher Key = "config.watcher"
+	KeyLogger        Key = "logger"
+	KeyContextLogger Key = "logger.context"
+	KeyCtxLogger     Key = "logger.ctx"
+	KeyTracer        Key = "tracer"
+	KeyHealth        Key = "health"
 )
 
-func GetAppEnv() env.AppEnv {
-	return env.Env(envAppEnv, env.EnvTest)
+// container holds the application components.
+var container = NewContainer()
+
+// configWatcher holds the *cfg.Watcher once it is built, so that GetConfig reflects reloads.
+var configWatcher atomic.Value
+
+type loadedConfig struct {
+	config  *cfg.Config
+	entries []cfg.Entry
 }
 
-func GetLogger() logging.Logger {
-	if !GetAppEnv().IsCloud() {
-		l, err := logging.NewDevelopmentLogger()
-		if err != nil {
-			log.Fatalf("failed to init logger: %+v", err)
-		}
+func init() {
+	Register(KeyConfig, provideConfig)
+	Register(KeyConfigWatcher, provideConfigWatcher)
+	Register(KeyLogger, provideLogger)
+	Register(KeyContextLogger, provideContextLogger)
+	Register(KeyCtxLogger, provideCtxLogger)
+	Register(KeyTracer, provideTracer)
+	Register(KeyHealth, provideHealth)
+}
 
-		return l
+// Default returns the container holding the application components.
+func Default() *Container {
+	return container
+}
+
+// Register registers the constructor of key in the default container.
+func Register(key Key, constructor Constructor) {
+	if err := container.Register(key, constructor); err != nil {
+		log.Fatalf("failed to register %s: %+v", key, err)
 	}
+}
 
-	l, err := logging.NewLogger(env.String(envLogLevel))
+// mustGet returns the component of key from the default container and stops the application on failure.
+func mustGet(key Key) interface{} {
+	v, err := container.Get(key)
 	if err != nil {
-		log.Fatalf("failed to init logger: %+v", err)
+		log.Fatalf("failed to init %s: %+v", key, err)
 	}
 
-	return l
+	return v
+}
+
+// GetConfig returns the application configuration, loaded and validated once.
+// Once the config watcher is built it reflects reloads, so callers should not keep the result.
+func GetConfig() *cfg.Config {
+	if w, ok := configWatcher.Load().(*cfg.Watcher); ok {
+		return w.Current()
+	}
+
+	return mustGet(KeyConfig).(*loadedConfig).config
+}
+
+func GetAppEnv() env.AppEnv {
+	return GetConfig().AppEnv
+}
+
+// loggers are built once and shared, so that level changes apply to every logger.
+
+func GetLogger() logging.Logger {
+	return mustGet(KeyLogger).(logging.Logger)
 }
 
 func GetMainLogger() logging.Logger {
@@ -39,22 +96,141 @@ func GetMainLogger() logging.Logger {
 }
 
 func GetContextLogger() logging.ContextLogger {
-	if !GetAppEnv().IsCloud() {
-		l, err := logging.NewDevelopmentContextLogger(logging.ContextParser)
-		if err != nil {
-			log.Fatalf("failed to init logger: %+v", err)
+	return mustGet(KeyContextLogger).(logging.ContextLogger)
+}
+
+// GetCtxLogger returns a logger reading request fields from context.Context.
+func GetCtxLogger() logging.CtxLogger {
+	return mustGet(KeyCtxLogger).(logging.CtxLogger)
+}
+
+// GetHealth returns the registry of liveness and readiness checks.
+func GetHealth() *health.Registry {
+	return mustGet(KeyHealth).(*health.Registry)
+}
+
+func provideConfig(r Resolver) (interface{}, error) {
+	c := new(cfg.Config)
+
+	entries, err := cfg.Describe(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, p := range c.RetryPolicies() {
+		retry.RegisterPolicy(name, p)
+	}
+
+	return &loadedConfig{config: c, entries: entries}, nil
+}
+
+// provideConfigWatcher reloads the configuration on config file changes and SIGHUP.
+func provideConfigWatcher(r Resolver) (interface{}, error) {
+	v, err := r.Get(KeyConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := v.(*loadedConfig)
+
+	v, err = r.Get(KeyLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	l := v.(logging.Logger).Named("config")
+
+	w := cfg.NewWatcher(loaded.config, loaded.entries, l)
+	w.Subscribe(func(prev, next *cfg.Config) {
+		// applying an unchanged level would cancel a temporary level set through the admin endpoint.
+		if next.Log.Level == prev.Log.Level {
+			return
+		}
+
+		if err := logging.GetLevels().SetGlobal(next.Log.Level, 0); err != nil {
+			l.Error("failed to apply log level", zap.Error(err))
 		}
+	})
+
+	r.OnStart(func(context.Context) error {
+		return w.Start()
+	})
+	r.OnStop(func(context.Context) error {
+		return w.Close()
+	})
+
+	configWatcher.Store(w)
+
+	return w, nil
+}
+
+func provideLogger(r Resolver) (interface{}, error) {
+	conf, err := resolveConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conf.AppEnv.IsCloud() {
+		return logging.NewDevelopmentLogger()
+	}
+
+	return logging.NewLogger(conf.Logging(serviceName))
+}
 
-		return l
+func provideContextLogger(r Resolver) (interface{}, error) {
+	conf, err := resolveConfig(r)
+	if err != nil {
+		return nil, err
 	}
 
-	l, err := logging.NewContextLogger(
-		env.String(envLogLevel),
-		logging.ContextParser,
-	)
+	if !conf.AppEnv.IsCloud() {
+		return logging.NewDevelopmentContextLogger(logging.ContextParser)
+	}
+
+	return logging.NewContextLogger(conf.Logging(serviceName), logging.ContextParser)
+}
+
+func provideCtxLogger(r Resolver) (interface{}, error) {
+	conf, err := resolveConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !conf.AppEnv.IsCloud() {
+		return logging.NewDevelopmentCtxLogger()
+	}
+
+	return logging.NewCtxLogger(conf.Logging(serviceName))
+}
+
+// provideTracer installs the global tracer provider, flushed on stop.
+func provideTracer(r Resolver) (interface{}, error) {
+	conf, err := resolveConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := tracing.Init(context.Background(), conf.Tracing(serviceName))
+	if err != nil {
+		return nil, err
+	}
+
+	r.OnStop(func(context.Context) error {
+		return c.Close()
+	})
+
+	return c, nil
+}
+
+func provideHealth(r Resolver) (interface{}, error) {
+	return health.New(), nil
+}
+
+func resolveConfig(r Resolver) (*cfg.Config, error) {
+	v, err := r.Get(KeyConfig)
 	if err != nil {
-		log.Fatalf("failed to init logger: %+v", err)
+		return nil, fmt.Errorf("failed to resolve config: %w", err)
 	}
 
-	return l
+	return v.(*loadedConfig).config, nil
 }
@@ -1,13 +1,19 @@
 package di
 
 import (
+	"context"
 	"fmt"
-	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/logging"
 	"go.uber.org/zap"
 )
 
-// var terminationGracePeriod = 3
+// terminationGracePeriod bounds the time given to OnStop hooks, in seconds, after the shutdown drain.
+var terminationGracePeriod = 3
 
 // LogInitFatal logs initialization error and then forces to stop application.
 func LogInitFatal(name string, err error) {
@@ -15,28 +21,34 @@ func LogInitFatal(name string, err error) {
 	GetLogger().Fatal(msg, zap.Error(err))
 }
 
+// Run starts the components built in the default container, blocks until SIGINT or SIGTERM,
+// then stops them in reverse dependency order.
+func Run() {
+	l := GetLogger().Named("lifecycle")
 
-type closerFunc struct {
-	key    string
-	closer io.Closer
-}
+	if err := container.Start(context.Background()); err != nil {
+		// stop what did start, e.g. flush the tracer, before exiting.
+		stop(l)
+		LogInitFatal("components", err)
+	}
 
-var closerFuncs = make([]*closerFunc, 0)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-// RegisterCloser registers the given closer.
-func RegisterCloser(key string, closer io.Closer) {
-	l := GetLogger().Named("closer")
+	sig := <-quit
+	l.Info(fmt.Sprintf("received %s, shutting down", sig))
 
-	for _, c := range closerFuncs {
-		if key == c.key {
-			l.Fatal(fmt.Sprintf("duplicate closer key: %s", key))
-		}
-	}
+	stop(l)
+}
+
+// stop stops the started components within the shutdown drain and the termination grace period.
+func stop(l logging.Logger) {
+	grace := GetConfig().Server.ShutdownDrain + time.Duration(terminationGracePeriod)*time.Second
 
-	closerFuncs = append(closerFuncs, &closerFunc{
-		key:    key,
-		closer: closer,
-	})
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
 
-	l.Info(fmt.Sprintf("%s closer is registered", key))
+	if err := container.Stop(ctx); err != nil {
+		l.Error("failed to stop components", zap.Error(err))
+	}
 }
@@ -1,15 +1,37 @@
 package error
 
-import (
-	"reflect"
-)
-
 // ErrCode type
 type ErrCode string
 
 const (
 	// ErrCodeDefault ...
 	ErrCodeDefault ErrCode = "default"
+	// ErrCodeValidation is for invalid input.
+	ErrCodeValidation ErrCode = "validation"
+	// ErrCodeUnauthorized is for missing or invalid credentials.
+	ErrCodeUnauthorized ErrCode = "unauthorized"
+	// ErrCodeForbidden is for authenticated users lacking permission.
+	ErrCodeForbidden ErrCode = "forbidden"
+	// ErrCodeNotFound is for missing resources.
+	ErrCodeNotFound ErrCode = "not_found"
+	// ErrCodeConflict is for requests conflicting with the current state of a resource.
+	ErrCodeConflict ErrCode = "conflict"
+	// ErrCodeRateLimited is for clients exceeding their rate limit.
+	ErrCodeRateLimited ErrCode = "rate_limited"
+	// ErrCodeInternal is for unexpected failures.
+	ErrCodeInternal ErrCode = "internal"
+	// ErrCodeUnavailable is for dependencies being temporarily unavailable.
+	ErrCodeUnavailable ErrCode = "unavailable"
+	// ErrCodeMethodNotAllowed is for routes not supporting the request method.
+	ErrCodeMethodNotAllowed ErrCode = "method_not_allowed"
+	// ErrCodeRequestTimeout is for requests not received in time.
+	ErrCodeRequestTimeout ErrCode = "request_timeout"
+	// ErrCodePayloadTooLarge is for request bodies exceeding the limit.
+	ErrCodePayloadTooLarge ErrCode = "payload_too_large"
+	// ErrCodeUnsupportedMediaType is for request bodies in an unsupported format.
+	ErrCodeUnsupportedMediaType ErrCode = "unsupported_media_type"
+	// ErrCodeHeaderTooLarge is for request headers exceeding the limit.
+	ErrCodeHeaderTooLarge ErrCode = "header_too_large"
 )
 
 // Error for managed errors
@@ -17,14 +39,98 @@ type Error struct {
 	Code    ErrCode     `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+
+	// cause is the wrapped error; it is never rendered to clients.
+	cause error
+
+	// stack is the call stack where the error was created.
+	stack []uintptr
+}
+
+// New returns a managed error. The default message of code is used when message is empty.
+func New(code ErrCode, message string, data interface{}) *Error {
+	if message == "" {
+		message = code.Message()
+	}
+
+	return &Error{Code: code, Message: message, Data: data, stack: callers()}
+}
+
+// Wrap returns a managed error caused by cause. The default message of code is used when message is empty.
+func Wrap(code ErrCode, cause error, message string) *Error {
+	e := New(code, message, nil)
+	e.cause = cause
+
+	return e
+}
+
+// Validation returns a managed error for invalid input, listing the rejected parameters.
+func Validation(message string, params ...InvalidParam) *Error {
+	return New(ErrCodeValidation, message, params)
+}
+
+// Unauthorized returns a managed error for missing or invalid credentials.
+func Unauthorized(message string) *Error {
+	return New(ErrCodeUnauthorized, message, nil)
+}
+
+// Forbidden returns a managed error for authenticated users lacking permission.
+func Forbidden(message string) *Error {
+	return New(ErrCodeForbidden, message, nil)
+}
+
+// NotFound returns a managed error for missing resources.
+func NotFound(message string) *Error {
+	return New(ErrCodeNotFound, message, nil)
+}
+
+// Conflict returns a managed error for requests conflicting with the current state of a resource.
+func Conflict(message string) *Error {
+	return New(ErrCodeConflict, message, nil)
+}
+
+// RateLimited returns a managed error for clients exceeding their rate limit.
+func RateLimited(message string) *Error {
+	return New(ErrCodeRateLimited, message, nil)
+}
+
+// Internal returns a managed error for unexpected failures.
+func Internal(message string) *Error {
+	return New(ErrCodeInternal, message, nil)
+}
+
+// Unavailable returns a managed error for dependencies being temporarily unavailable.
+func Unavailable(message string) *Error {
+	return New(ErrCodeUnavailable, message, nil)
 }
 
 // Error interface
 func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+
 	return e.Message
 }
 
-// Is interface
+// Unwrap returns the wrapped cause.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether err is a managed error with the same code,
+// so that errors.Is(err, &Error{Code: ErrCodeNotFound}) matches any not-found error.
 func (e *Error) Is(err error) bool {
-	return reflect.TypeOf(e) == reflect.TypeOf(err)
+	target, ok := err.(*Error)
+	return ok && target.Code == e.Code
+}
+
+// Status returns the HTTP status of the error code.
+func (e *Error) Status() int {
+	return e.Code.Status()
+}
+
+// Retryable reports whether the request may succeed when retried.
+func (e *Error) Retryable() bool {
+	return e.Code.Retryable()
 }
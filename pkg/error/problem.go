@@ -0,0 +1,41 @@
+package error
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypeBaseURI prefixes the code to build the problem type URI.
+var ProblemTypeBaseURI = "https://kbpartpicker.com/problems/"
+
+// InvalidParam describes why a request parameter was rejected.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// Problem is an RFC 7807 problem details object.
+// https://datatracker.ietf.org/doc/html/rfc7807
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// Problem returns the problem details of the error. instance identifies the occurrence, e.g. the request ID.
+func (e *Error) Problem(instance string) Problem {
+	p := Problem{
+		Type:     ProblemTypeBaseURI + string(e.Code),
+		Title:    e.Code.Message(),
+		Status:   e.Status(),
+		Detail:   e.Message,
+		Instance: instance,
+	}
+
+	if params, ok := e.Data.([]InvalidParam); ok {
+		p.InvalidParams = params
+	}
+
+	return p
+}
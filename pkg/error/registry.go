@@ -0,0 +1,129 @@
+package error
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CodeSpec describes how an ErrCode is exposed to clients.
+type CodeSpec struct {
+	Status    int
+	Message   string
+	Retryable bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[ErrCode]CodeSpec{}
+
+	// codes keeps registration order so that CodeForStatus is deterministic.
+	codes []ErrCode
+)
+
+func init() {
+	Register(ErrCodeDefault, CodeSpec{Status: http.StatusBadRequest, Message: "bad request"})
+	Register(ErrCodeValidation, CodeSpec{Status: http.StatusBadRequest, Message: "validation failed"})
+	Register(ErrCodeUnauthorized, CodeSpec{Status: http.StatusUnauthorized, Message: "unauthorized"})
+	Register(ErrCodeForbidden, CodeSpec{Status: http.StatusForbidden, Message: "forbidden"})
+	Register(ErrCodeNotFound, CodeSpec{Status: http.StatusNotFound, Message: "not found"})
+	Register(ErrCodeConflict, CodeSpec{Status: http.StatusConflict, Message: "conflict"})
+	Register(ErrCodeRateLimited, CodeSpec{Status: http.StatusTooManyRequests, Message: "too many requests", Retryable: true})
+	Register(ErrCodeInternal, CodeSpec{Status: http.StatusInternalServerError, Message: "internal server error"})
+	Register(ErrCodeUnavailable, CodeSpec{Status: http.StatusServiceUnavailable, Message: "service unavailable", Retryable: true})
+	Register(ErrCodeMethodNotAllowed, CodeSpec{Status: http.StatusMethodNotAllowed, Message: "method not allowed"})
+	Register(ErrCodeRequestTimeout, CodeSpec{Status: http.StatusRequestTimeout, Message: "request timeout", Retryable: true})
+	Register(ErrCodePayloadTooLarge, CodeSpec{Status: http.StatusRequestEntityTooLarge, Message: "payload too large"})
+	Register(ErrCodeUnsupportedMediaType, CodeSpec{Status: http.StatusUnsupportedMediaType, Message: "unsupported media type"})
+	Register(ErrCodeHeaderTooLarge, CodeSpec{Status: http.StatusRequestHeaderFieldsTooLarge, Message: "request header fields too large"})
+}
+
+// httpCodePrefix prefixes the generic codes of statuses without a registered code.
+const httpCodePrefix = "http_"
+
+// Register registers or replaces the spec of code.
+func Register(code ErrCode, spec CodeSpec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[code]; !ok {
+		codes = append(codes, code)
+	}
+
+	registry[code] = spec
+}
+
+// Codes returns all registered codes in registration order.
+func Codes() []ErrCode {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]ErrCode(nil), codes...)
+}
+
+// Spec returns the spec of the code. Generic codes returned by CodeForStatus carry their
+// status; other unregistered codes are treated as internal errors.
+func (c ErrCode) Spec() CodeSpec {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if spec, ok := registry[c]; ok {
+		return spec
+	}
+
+	if spec, ok := httpCodeSpec(c); ok {
+		return spec
+	}
+
+	return registry[ErrCodeInternal]
+}
+
+// Status returns the HTTP status of the code.
+func (c ErrCode) Status() int {
+	return c.Spec().Status
+}
+
+// Message returns the default message of the code.
+func (c ErrCode) Message() string {
+	return c.Spec().Message
+}
+
+// Retryable reports whether requests failing with the code may succeed when retried.
+func (c ErrCode) Retryable() bool {
+	return c.Spec().Retryable
+}
+
+// CodeForStatus returns the first registered code with the HTTP status,
+// or a generic code such as "http_418" when there is none.
+func CodeForStatus(status int) ErrCode {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, c := range codes {
+		if registry[c].Status == status {
+			return c
+		}
+	}
+
+	if http.StatusText(status) == "" {
+		return ErrCodeInternal
+	}
+
+	return ErrCode(fmt.Sprintf("%s%d", httpCodePrefix, status))
+}
+
+// httpCodeSpec returns the spec of a generic code returned by CodeForStatus.
+func httpCodeSpec(c ErrCode) (CodeSpec, bool) {
+	if !strings.HasPrefix(string(c), httpCodePrefix) {
+		return CodeSpec{}, false
+	}
+
+	status, err := strconv.Atoi(strings.TrimPrefix(string(c), httpCodePrefix))
+	if err != nil || http.StatusText(status) == "" {
+		return CodeSpec{}, false
+	}
+
+	return CodeSpec{Status: status, Message: strings.ToLower(http.StatusText(status))}, true
+}
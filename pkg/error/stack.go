@@ -0,0 +1,60 @@
+package error
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const (
+	maxStackDepth = 32
+	packagePrefix = "github.com/puipuipartpicker/kbpartpicker/api/pkg/error."
+)
+
+func callers() []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	// skip runtime.Callers and callers itself.
+	n := runtime.Callers(2, pcs)
+
+	return pcs[:n]
+}
+
+// StackTrace returns the call stack where the error was created, excluding the constructors of this package.
+func (e *Error) StackTrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, packagePrefix) {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// Chain returns the messages of err and of each error it wraps, outermost first.
+func Chain(err error) []string {
+	chain := []string{}
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if managed, ok := err.(*Error); ok {
+			chain = append(chain, fmt.Sprintf("%s: %s", managed.Code, managed.Message))
+			continue
+		}
+
+		chain = append(chain, err.Error())
+	}
+
+	return chain
+}
@@ -0,0 +1,19 @@
+package health
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler returns a fiber handler running the checks of kind, responding 503 when any is down.
+func (r *Registry) Handler(kind Kind) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := r.Run(c.UserContext(), kind)
+
+		status := fiber.StatusOK
+		if report.Status == StatusDown {
+			status = fiber.StatusServiceUnavailable
+		}
+
+		return c.Status(status).JSON(report)
+	}
+}
@@ -0,0 +1,212 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Kind tells whether a check reports liveness or readiness.
+type Kind string
+
+const (
+	// Liveness checks fail when the process must be restarted.
+	Liveness Kind = "liveness"
+	// Readiness checks fail when the process must not receive traffic.
+	Readiness Kind = "readiness"
+)
+
+// Status of a check or a report.
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// defaultCacheTTL bounds how often checks run when probes are polled by several clients.
+const defaultCacheTTL = 2 * time.Second
+
+// defaultTimeout bounds a single check.
+const defaultTimeout = 3 * time.Second
+
+var errShuttingDown = errors.New("shutting down")
+
+// Check reports an error when the checked component is unhealthy.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check.
+type Result struct {
+	Status    Status    `json:"status"`
+	LatencyMs float64   `json:"latencyMs"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report is the outcome of every check of a kind.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name  string
+	kind  Kind
+	check Check
+
+	mu     sync.Mutex
+	result Result
+}
+
+// Registry holds the checks registered by components.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []*check
+
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	shuttingDown chan struct{}
+	shutdownOnce sync.Once
+
+	// now is replaced by tests.
+	now func() time.Time
+}
+
+// Option configures a Registry.
+type Option func(*Registry)
+
+// WithCacheTTL sets how long a check result is reused.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(r *Registry) {
+		r.cacheTTL = ttl
+	}
+}
+
+// WithTimeout sets the timeout of a single check.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Registry) {
+		r.timeout = timeout
+	}
+}
+
+// New returns an empty registry.
+func New(opts ...Option) *Registry {
+	r := &Registry{
+		cacheTTL:     defaultCacheTTL,
+		timeout:      defaultTimeout,
+		shuttingDown: make(chan struct{}),
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// AddLiveness registers a liveness check.
+func (r *Registry) AddLiveness(name string, c Check) {
+	r.add(name, Liveness, c)
+}
+
+// AddReadiness registers a readiness check.
+func (r *Registry) AddReadiness(name string, c Check) {
+	r.add(name, Readiness, c)
+}
+
+func (r *Registry) add(name string, kind Kind, c Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, &check{name: name, kind: kind, check: c})
+}
+
+// Shutdown makes readiness fail from now on, so that load balancers drain the process
+// before its servers stop.
+func (r *Registry) Shutdown() {
+	r.shutdownOnce.Do(func() {
+		close(r.shuttingDown)
+	})
+}
+
+func (r *Registry) isShuttingDown() bool {
+	select {
+	case <-r.shuttingDown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run runs the checks of kind concurrently, reusing results younger than the cache TTL.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.kind == kind {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks)+1)}
+
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+
+		if results[i].Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+
+	if kind == Readiness && r.isShuttingDown() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = Result{Status: StatusDown, Error: errShuttingDown.Error(), CheckedAt: r.now()}
+	}
+
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.result.CheckedAt.IsZero() && r.now().Sub(c.result.CheckedAt) < r.cacheTTL {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := r.now()
+	err := c.check(ctx)
+
+	c.result = Result{
+		Status:    StatusUp,
+		LatencyMs: float64(r.now().Sub(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+
+	if err != nil {
+		c.result.Status = StatusDown
+		c.result.Error = err.Error()
+	}
+
+	return c.result
+}
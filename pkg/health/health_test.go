@@ -0,0 +1,208 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source that only moves when advanced.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func newRegistry(c *clock, opts ...Option) *Registry {
+	r := New(opts...)
+	r.now = c.Now
+
+	return r
+}
+
+// counting returns a check returning err and counting its calls.
+func counting(calls *int32, err *error) Check {
+	return func(context.Context) error {
+		atomic.AddInt32(calls, 1)
+		return *err
+	}
+}
+
+func TestRegistryRun(t *testing.T) {
+	r := newRegistry(newClock())
+
+	var calls int32
+
+	up, down := error(nil), errors.New("ping: connection refused")
+
+	r.AddLiveness("process", counting(&calls, &up))
+	r.AddReadiness("mongo", counting(&calls, &up))
+	r.AddReadiness("changeStream", counting(&calls, &down))
+
+	live := r.Run(context.Background(), Liveness)
+	if live.Status != StatusUp || len(live.Checks) != 1 || live.Checks["process"].Status != StatusUp {
+		t.Fatalf("liveness = %+v, want process up", live)
+	}
+
+	ready := r.Run(context.Background(), Readiness)
+	if ready.Status != StatusDown || len(ready.Checks) != 2 {
+		t.Fatalf("readiness = %+v, want down with two checks", ready)
+	}
+
+	if got := ready.Checks["changeStream"]; got.Status != StatusDown || got.Error != down.Error() {
+		t.Fatalf("changeStream = %+v, want down with %q", got, down)
+	}
+
+	if got := ready.Checks["mongo"]; got.Status != StatusUp || got.Error != "" {
+		t.Fatalf("mongo = %+v, want up", got)
+	}
+
+	if calls != 3 {
+		t.Fatalf("ran %d checks, want 3", calls)
+	}
+}
+
+func TestRegistryCachesResults(t *testing.T) {
+	c := newClock()
+	r := newRegistry(c, WithCacheTTL(2*time.Second))
+
+	var (
+		calls int32
+		err   error
+	)
+
+	r.AddReadiness("mongo", counting(&calls, &err))
+
+	first := r.Run(context.Background(), Readiness)
+	if !first.Checks["mongo"].CheckedAt.Equal(c.Now()) {
+		t.Fatalf("CheckedAt = %s, want %s", first.Checks["mongo"].CheckedAt, c.Now())
+	}
+
+	// a failure within the TTL is not seen yet.
+	err = errors.New("down")
+	c.Advance(time.Second)
+
+	if report := r.Run(context.Background(), Readiness); report.Status != StatusUp || calls != 1 {
+		t.Fatalf("report = %+v after %d calls, want the cached result", report, calls)
+	}
+
+	c.Advance(time.Second)
+
+	report := r.Run(context.Background(), Readiness)
+	if report.Status != StatusDown || calls != 2 {
+		t.Fatalf("report = %+v after %d calls, want the check run again", report, calls)
+	}
+
+	if !report.Checks["mongo"].CheckedAt.Equal(c.Now()) {
+		t.Fatalf("CheckedAt = %s, want %s", report.Checks["mongo"].CheckedAt, c.Now())
+	}
+}
+
+func TestRegistryTimesOutChecks(t *testing.T) {
+	r := newRegistry(newClock(), WithTimeout(10*time.Millisecond))
+
+	r.AddReadiness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	done := make(chan Report, 1)
+
+	go func() {
+		done <- r.Run(context.Background(), Readiness)
+	}()
+
+	select {
+	case report := <-done:
+		if got := report.Checks["slow"]; got.Status != StatusDown || got.Error != context.DeadlineExceeded.Error() {
+			t.Fatalf("slow = %+v, want down with %v", got, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not time out the check")
+	}
+}
+
+func TestRegistryShutdown(t *testing.T) {
+	c := newClock()
+	r := newRegistry(c)
+
+	var (
+		calls int32
+		err   error
+	)
+
+	r.AddLiveness("process", counting(&calls, &err))
+	r.AddReadiness("mongo", counting(&calls, &err))
+
+	if report := r.Run(context.Background(), Readiness); report.Status != StatusUp {
+		t.Fatalf("readiness = %+v, want up", report)
+	}
+
+	r.Shutdown()
+	// a second call must not panic on the closed channel.
+	r.Shutdown()
+
+	report := r.Run(context.Background(), Readiness)
+	if report.Status != StatusDown || report.Checks["mongo"].Status != StatusUp {
+		t.Fatalf("readiness = %+v, want down with mongo up", report)
+	}
+
+	if got := report.Checks["shutdown"]; got.Status != StatusDown || got.Error != errShuttingDown.Error() || !got.CheckedAt.Equal(c.Now()) {
+		t.Fatalf("shutdown = %+v, want down", got)
+	}
+
+	// the process is still alive while it drains.
+	if report := r.Run(context.Background(), Liveness); report.Status != StatusUp {
+		t.Fatalf("liveness = %+v, want up", report)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	c := newClock()
+
+	h := NewHeartbeat(time.Minute)
+	h.now = c.Now
+	h.Beat()
+
+	r := newRegistry(c, WithCacheTTL(0))
+	r.AddLiveness("scheduler", h.Check)
+
+	c.Advance(time.Minute)
+
+	if report := r.Run(context.Background(), Liveness); report.Status != StatusUp {
+		t.Fatalf("liveness = %+v, want up at maxAge", report)
+	}
+
+	c.Advance(time.Second)
+
+	report := r.Run(context.Background(), Liveness)
+	if got := report.Checks["scheduler"]; got.Status != StatusDown || got.Error != "last heartbeat 1m1s ago exceeds 1m0s" {
+		t.Fatalf("scheduler = %+v, want down", got)
+	}
+
+	h.Beat()
+
+	if report := r.Run(context.Background(), Liveness); report.Status != StatusUp {
+		t.Fatalf("liveness = %+v, want up after a beat", report)
+	}
+}
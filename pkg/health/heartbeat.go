@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Heartbeat is a check for background loops, e.g. schedulers: it fails when Beat
+// has not been called within maxAge.
+type Heartbeat struct {
+	maxAge time.Duration
+	last   int64 // unix nanoseconds
+
+	// now is replaced by tests.
+	now func() time.Time
+}
+
+// NewHeartbeat returns a heartbeat considered fresh until maxAge has elapsed.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	return &Heartbeat{maxAge: maxAge, last: time.Now().UnixNano(), now: time.Now}
+}
+
+// Beat records that the loop is alive.
+func (h *Heartbeat) Beat() {
+	atomic.StoreInt64(&h.last, h.now().UnixNano())
+}
+
+// Check implements Check.
+func (h *Heartbeat) Check(context.Context) error {
+	age := h.now().Sub(time.Unix(0, atomic.LoadInt64(&h.last)))
+	if age > h.maxAge {
+		return fmt.Errorf("last heartbeat %s ago exceeds %s", age.Round(time.Millisecond), h.maxAge)
+	}
+
+	return nil
+}
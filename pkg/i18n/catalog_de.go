@@ -0,0 +1,29 @@
+package i18n
+
+var catalogDe = map[Key]string{
+	"error.default":      "Ungültige Anfrage",
+	"error.validation":   "Validierung fehlgeschlagen",
+	"error.unauthorized": "Nicht authentifiziert",
+	"error.forbidden":    "Zugriff verweigert",
+	"error.not_found":    "Nicht gefunden",
+	"error.conflict":     "Konflikt mit dem aktuellen Zustand",
+	"error.rate_limited": "Zu viele Anfragen",
+	"error.internal":     "Interner Serverfehler",
+	"error.unavailable":  "Dienst vorübergehend nicht verfügbar",
+
+	"switch_type.linear":  "Linear",
+	"switch_type.tactile": "Taktil",
+	"switch_type.clicky":  "Klickend",
+
+	"mounting_style.gasket": "Gasket-Mount",
+	"mounting_style.top":    "Top-Mount",
+	"mounting_style.tray":   "Tray-Mount",
+	"mounting_style.bottom": "Bottom-Mount",
+
+	"profile.cherry": "Cherry-Profil",
+	"profile.oem":    "OEM-Profil",
+	"profile.sa":     "SA-Profil",
+	"profile.dsa":    "DSA-Profil",
+	"profile.xda":    "XDA-Profil",
+	"profile.mt3":    "MT3-Profil",
+}
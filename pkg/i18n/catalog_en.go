@@ -0,0 +1,21 @@
+package i18n
+
+// catalogEn holds the English catalog strings. English error messages are the default
+// messages of the error code registry.
+var catalogEn = map[Key]string{
+	"switch_type.linear":  "Linear",
+	"switch_type.tactile": "Tactile",
+	"switch_type.clicky":  "Clicky",
+
+	"mounting_style.gasket": "Gasket mount",
+	"mounting_style.top":    "Top mount",
+	"mounting_style.tray":   "Tray mount",
+	"mounting_style.bottom": "Bottom mount",
+
+	"profile.cherry": "Cherry profile",
+	"profile.oem":    "OEM profile",
+	"profile.sa":     "SA profile",
+	"profile.dsa":    "DSA profile",
+	"profile.xda":    "XDA profile",
+	"profile.mt3":    "MT3 profile",
+}
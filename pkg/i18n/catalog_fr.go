@@ -0,0 +1,29 @@
+package i18n
+
+var catalogFr = map[Key]string{
+	"error.default":      "Requête invalide",
+	"error.validation":   "La validation a échoué",
+	"error.unauthorized": "Non authentifié",
+	"error.forbidden":    "Accès refusé",
+	"error.not_found":    "Introuvable",
+	"error.conflict":     "Conflit avec l'état actuel",
+	"error.rate_limited": "Trop de requêtes",
+	"error.internal":     "Erreur interne du serveur",
+	"error.unavailable":  "Service temporairement indisponible",
+
+	"switch_type.linear":  "Linéaire",
+	"switch_type.tactile": "Tactile",
+	"switch_type.clicky":  "À clic",
+
+	"mounting_style.gasket": "Montage gasket",
+	"mounting_style.top":    "Montage top",
+	"mounting_style.tray":   "Montage tray",
+	"mounting_style.bottom": "Montage bottom",
+
+	"profile.cherry": "Profil Cherry",
+	"profile.oem":    "Profil OEM",
+	"profile.sa":     "Profil SA",
+	"profile.dsa":    "Profil DSA",
+	"profile.xda":    "Profil XDA",
+	"profile.mt3":    "Profil MT3",
+}
@@ -0,0 +1,29 @@
+package i18n
+
+var catalogJa = map[Key]string{
+	"error.default":      "不正なリクエストです",
+	"error.validation":   "入力の検証に失敗しました",
+	"error.unauthorized": "認証が必要です",
+	"error.forbidden":    "権限がありません",
+	"error.not_found":    "見つかりません",
+	"error.conflict":     "現在の状態と競合しています",
+	"error.rate_limited": "リクエストが多すぎます",
+	"error.internal":     "内部サーバーエラーが発生しました",
+	"error.unavailable":  "サービスは一時的に利用できません",
+
+	"switch_type.linear":  "リニア",
+	"switch_type.tactile": "タクタイル",
+	"switch_type.clicky":  "クリッキー",
+
+	"mounting_style.gasket": "ガスケットマウント",
+	"mounting_style.top":    "トップマウント",
+	"mounting_style.tray":   "トレイマウント",
+	"mounting_style.bottom": "ボトムマウント",
+
+	"profile.cherry": "チェリープロファイル",
+	"profile.oem":    "OEMプロファイル",
+	"profile.sa":     "SAプロファイル",
+	"profile.dsa":    "DSAプロファイル",
+	"profile.xda":    "XDAプロファイル",
+	"profile.mt3":    "MT3プロファイル",
+}
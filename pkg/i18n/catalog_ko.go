@@ -0,0 +1,29 @@
+package i18n
+
+var catalogKo = map[Key]string{
+	"error.default":      "잘못된 요청입니다",
+	"error.validation":   "입력값 검증에 실패했습니다",
+	"error.unauthorized": "인증이 필요합니다",
+	"error.forbidden":    "권한이 없습니다",
+	"error.not_found":    "찾을 수 없습니다",
+	"error.conflict":     "현재 상태와 충돌합니다",
+	"error.rate_limited": "요청이 너무 많습니다",
+	"error.internal":     "내부 서버 오류가 발생했습니다",
+	"error.unavailable":  "서비스를 일시적으로 사용할 수 없습니다",
+
+	"switch_type.linear":  "리니어",
+	"switch_type.tactile": "택타일",
+	"switch_type.clicky":  "클릭",
+
+	"mounting_style.gasket": "가스켓 마운트",
+	"mounting_style.top":    "탑 마운트",
+	"mounting_style.tray":   "트레이 마운트",
+	"mounting_style.bottom": "바텀 마운트",
+
+	"profile.cherry": "체리 프로파일",
+	"profile.oem":    "OEM 프로파일",
+	"profile.sa":     "SA 프로파일",
+	"profile.dsa":    "DSA 프로파일",
+	"profile.xda":    "XDA 프로파일",
+	"profile.mt3":    "MT3 프로파일",
+}
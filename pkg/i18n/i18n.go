@@ -0,0 +1,88 @@
+package i18n
+
+import (
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"golang.org/x/text/language"
+)
+
+// Key identifies a message in the catalogs.
+type Key string
+
+// Fallback is the language used when no supported language matches or a message is missing.
+var Fallback = language.English
+
+// catalogs holds the messages of each supported language.
+var catalogs = map[language.Tag]map[Key]string{
+	language.English:  catalogEn,
+	language.Korean:   catalogKo,
+	language.Japanese: catalogJa,
+	language.German:   catalogDe,
+	language.French:   catalogFr,
+}
+
+// supported lists the languages in matcher preference order; the fallback comes first.
+var supported = []language.Tag{
+	language.English,
+	language.Korean,
+	language.Japanese,
+	language.German,
+	language.French,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// ErrorKey returns the key of the message of an error code.
+func ErrorKey(code appErr.ErrCode) Key {
+	return Key("error." + string(code))
+}
+
+// SwitchTypeKey returns the key of a switch type name, e.g. "linear".
+func SwitchTypeKey(switchType string) Key {
+	return Key("switch_type." + switchType)
+}
+
+// MountingStyleKey returns the key of a mounting style name, e.g. "gasket".
+func MountingStyleKey(style string) Key {
+	return Key("mounting_style." + style)
+}
+
+// ProfileKey returns the key of a keycap profile name, e.g. "cherry".
+func ProfileKey(profile string) Key {
+	return Key("profile." + profile)
+}
+
+// Match returns the supported language best matching an Accept-Language header.
+func Match(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Fallback
+	}
+
+	_, i, _ := matcher.Match(tags...)
+
+	return supported[i]
+}
+
+// ErrorMessage returns the message of code in lang, falling back to the default message
+// of the code in the error registry, which is English. It also returns the language of
+// the message, for the Content-Language header.
+func ErrorMessage(lang language.Tag, code appErr.ErrCode) (string, language.Tag) {
+	if msg, ok := catalogs[lang][ErrorKey(code)]; ok {
+		return msg, lang
+	}
+
+	return code.Message(), Fallback
+}
+
+// T returns the message of key in lang, falling back to English and then to the key itself.
+func T(lang language.Tag, key Key) string {
+	if msg, ok := catalogs[lang][key]; ok {
+		return msg
+	}
+
+	if msg, ok := catalogs[Fallback][key]; ok {
+		return msg
+	}
+
+	return string(key)
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"strings"
+	"testing"
+
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"golang.org/x/text/language"
+)
+
+func TestErrorCodesHaveEnglishMessages(t *testing.T) {
+	for _, code := range appErr.Codes() {
+		if code.Message() == "" {
+			t.Errorf("error code %q is registered without message", code)
+		}
+
+		// every language falls back to the registry message, English included.
+		for _, lang := range supported {
+			if msg, _ := ErrorMessage(lang, code); msg == "" {
+				t.Errorf("%s: error code %q has no message", lang, code)
+			}
+		}
+	}
+}
+
+func TestCatalogKeys(t *testing.T) {
+	registered := map[Key]bool{}
+	for _, code := range appErr.Codes() {
+		registered[ErrorKey(code)] = true
+	}
+
+	for lang, catalog := range catalogs {
+		for key := range catalog {
+			if strings.HasPrefix(string(key), "error.") {
+				if lang == Fallback {
+					t.Errorf("%s: English error messages belong to the error registry: %q", lang, key)
+				} else if !registered[key] {
+					t.Errorf("%s: message of unregistered error code: %q", lang, key)
+				}
+
+				continue
+			}
+
+			if _, ok := catalogEn[key]; !ok {
+				t.Errorf("%s: key without English message: %q", lang, key)
+			}
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           language.Tag
+	}{
+		{"", language.English},
+		{"ko-KR,ko;q=0.9,en;q=0.8", language.Korean},
+		{"ja", language.Japanese},
+		{"fr-CH, fr;q=0.9", language.French},
+		{"es", language.English},
+		{"not a language", language.English},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Match(%q) = %s, want %s", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		lang     language.Tag
+		code     appErr.ErrCode
+		want     string
+		wantLang language.Tag
+	}{
+		{language.Korean, appErr.ErrCodeNotFound, catalogKo[ErrorKey(appErr.ErrCodeNotFound)], language.Korean},
+		// the registry default is English, whatever the language asked for.
+		{language.Korean, appErr.ErrCodeMethodNotAllowed, appErr.ErrCodeMethodNotAllowed.Message(), language.English},
+		{language.English, appErr.CodeForStatus(418), "i'm a teapot", language.English},
+	}
+
+	for _, tt := range tests {
+		if got, lang := ErrorMessage(tt.lang, tt.code); got != tt.want || lang != tt.wantLang {
+			t.Errorf("ErrorMessage(%s, %s) = %q, %s, want %q, %s", tt.lang, tt.code, got, lang, tt.want, tt.wantLang)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Access is a fiber middleware logging each request once its handler returned.
+// It must wrap the error handler, so that error responses are logged as rendered.
+// It populates the user context with the route beforehand, so that CtxLogger
+// in the data layer logs the same fields.
+func (l *contextLogger) Access(ctx *fiber.Ctx) error {
+	route := &routeRef{ctx: ctx}
+	defer route.freeze()
+
+	ctx.SetUserContext(context.WithValue(ctx.UserContext(), routeCtxKey, route))
+
+	if _, ok := l.skipPaths[ctx.Path()]; ok {
+		return ctx.Next()
+	}
+
+	start := time.Now()
+	err := ctx.Next()
+	latency := time.Since(start)
+
+	route.freeze()
+
+	status, code := accessStatus(ctx, err)
+
+	var fields []zapcore.Field
+
+	if l.cloud {
+		fields = append(FieldsFromContext(ctx.UserContext()),
+			zap.Object(cloudHTTPRequestKey, httpRequest{
+				RequestMethod: ctx.Method(),
+				RequestURL:    RedactURI(ctx.Request().URI().String()),
+				Status:        status,
+				RequestSize:   len(ctx.Request().Body()),
+				ResponseSize:  len(ctx.Response().Body()),
+				UserAgent:     ctx.Get(fiber.HeaderUserAgent),
+				RemoteIP:      ctx.IP(),
+				ServerIP:      ctx.Context().LocalAddr().String(),
+				Referer:       RedactURI(ctx.Get(fiber.HeaderReferer)),
+				Latency:       latency,
+				Protocol:      ctx.Protocol(),
+			}),
+		)
+	} else {
+		fields = append(l.parseContext(ctx),
+			zap.Int("status", status),
+			zap.Duration("latency", latency),
+			zap.Int("bytesIn", len(ctx.Request().Body())),
+			zap.Int("bytesOut", len(ctx.Response().Body())),
+			zap.String("userAgent", ctx.Get(fiber.HeaderUserAgent)),
+			zap.String("referer", RedactURI(ctx.Get(fiber.HeaderReferer))),
+		)
+	}
+
+	if code != "" {
+		fields = append(fields, zap.String("errorCode", string(code)))
+	}
+
+	if ce := l.access.Check(accessLevel(status), "access"); ce != nil {
+		ce.Write(fields...)
+	}
+
+	return err
+}
+
+// SkipAccess returns a logger whose Access middleware does not log requests to the given paths,
+// such as the health check.
+func (l *contextLogger) SkipAccess(paths ...string) ContextLogger {
+	skip := make(map[string]struct{}, len(l.skipPaths)+len(paths))
+	for p := range l.skipPaths {
+		skip[p] = struct{}{}
+	}
+
+	for _, p := range paths {
+		skip[p] = struct{}{}
+	}
+
+	return newContextLogger(l.logger, l.contextParser, skip, l.cloud)
+}
+
+// errorCodeLocal is the fiber local SetErrorCode stores the code of rendered errors under.
+const errorCodeLocal = "logging.errorCode"
+
+// SetErrorCode records the code of the error rendered for ctx, for Access to log it
+// as errorCode once the error handler it wraps has turned the error into a response.
+func SetErrorCode(ctx *fiber.Ctx, code appErr.ErrCode) {
+	ctx.Locals(errorCodeLocal, code)
+}
+
+// accessStatus returns the status the response will be sent with and the code of a managed error.
+// Errors left unrendered, when Access is not wrapping the error handler, are rendered by fiber
+// afterwards, so the status is derived from err when there is one.
+func accessStatus(ctx *fiber.Ctx, err error) (int, appErr.ErrCode) {
+	if err == nil {
+		code, _ := ctx.Locals(errorCodeLocal).(appErr.ErrCode)
+		return ctx.Response().StatusCode(), code
+	}
+
+	var managed *appErr.Error
+	if errors.As(err, &managed) {
+		return managed.Status(), managed.Code
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code, ""
+	}
+
+	return http.StatusInternalServerError, ""
+}
+
+func accessLevel(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	appErr "github.com/puipuipartpicker/kbpartpicker/api/pkg/error"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newObservedLogger returns a context logger built like in production, writing to the returned logs.
+func newObservedLogger(t *testing.T) (ContextLogger, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	l, err := NewContextLogger(Config{Level: "debug"}, ContextParser, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return core
+	}))
+	if err != nil {
+		t.Fatalf("NewContextLogger() error = %v", err)
+	}
+
+	return l, logs
+}
+
+// accessEntry serves a request to app and returns the single access entry logged for it.
+func accessEntry(t *testing.T, app *fiber.App, logs *observer.ObservedLogs, target string) observer.LoggedEntry {
+	t.Helper()
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil)); err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+
+	entries := logs.FilterMessage("access").TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("access entries = %d, want 1", len(entries))
+	}
+
+	return entries[0]
+}
+
+func TestAccessCaller(t *testing.T) {
+	l, logs := newObservedLogger(t)
+
+	app := fiber.New()
+	app.Use(l.Access)
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusInternalServerError)
+	})
+
+	e := accessEntry(t, app, logs, "/fail")
+
+	if e.Level != zapcore.ErrorLevel {
+		t.Fatalf("level = %s, want error", e.Level)
+	}
+
+	if !e.Caller.Defined || !strings.HasSuffix(e.Caller.File, "pkg/logging/access.go") {
+		t.Fatalf("caller = %s, want access.go", e.Caller.String())
+	}
+
+	if e.Stack != "" {
+		t.Fatalf("stack = %q, want none", e.Stack)
+	}
+}
+
+func TestAccessLogsRenderedErrors(t *testing.T) {
+	l, logs := newObservedLogger(t)
+
+	const body = `{"code":"not_found"}`
+
+	app := fiber.New()
+	app.Use(l.Access)
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+
+		var managed *appErr.Error
+		if !errors.As(err, &managed) {
+			return err
+		}
+
+		SetErrorCode(c, managed.Code)
+
+		return c.Status(managed.Status()).SendString(body)
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return appErr.NotFound("")
+	})
+
+	fields := accessEntry(t, app, logs, "/missing").ContextMap()
+
+	if fields["status"] != int64(http.StatusNotFound) || fields["errorCode"] != string(appErr.ErrCodeNotFound) {
+		t.Fatalf("fields = %v, want the rendered status and code", fields)
+	}
+
+	if fields["bytesOut"] != int64(len(body)) {
+		t.Fatalf("bytesOut = %v, want the %d bytes of the rendered body", fields["bytesOut"], len(body))
+	}
+}
+
+func TestAccessPopulatesRoute(t *testing.T) {
+	l, logs := newObservedLogger(t)
+
+	var (
+		during string
+		saved  context.Context
+	)
+
+	app := fiber.New()
+	app.Use(l.Access)
+	app.Get("/parts/:id", func(c *fiber.Ctx) error {
+		during = RouteFromContext(c.UserContext())
+		saved = c.UserContext()
+
+		return c.SendStatus(http.StatusNoContent)
+	})
+
+	fields := accessEntry(t, app, logs, "/parts/42").ContextMap()
+
+	if fields["route"] != "/parts/:id" {
+		t.Fatalf("access route = %v, want the template", fields["route"])
+	}
+
+	if during != "/parts/:id" {
+		t.Fatalf("route in the handler = %q, want the template", during)
+	}
+
+	// the context may outlive the request, whose fiber context is then reused.
+	if got := RouteFromContext(saved); got != "/parts/:id" {
+		t.Fatalf("route after the request = %q, want the template", got)
+	}
+}
+
+func TestWithRoute(t *testing.T) {
+	ctx := WithRoute(context.Background(), "/v1/parts")
+
+	fields := FieldsFromContext(ctx)
+	if len(fields) != 1 || fields[0].Key != "route" || fields[0].String != "/v1/parts" {
+		t.Fatalf("fields = %v, want the route", fields)
+	}
+
+	if got := RouteFromContext(context.Background()); got != "" {
+		t.Fatalf("RouteFromContext() = %q, want none", got)
+	}
+}
@@ -0,0 +1,205 @@
+package logging
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Google Cloud Logging special fields.
+// https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+const (
+	cloudTraceKey          = "logging.googleapis.com/trace"
+	cloudSpanIDKey         = "logging.googleapis.com/spanId"
+	cloudSourceLocationKey = "logging.googleapis.com/sourceLocation"
+	cloudHTTPRequestKey    = "httpRequest"
+
+	// https://cloud.google.com/error-reporting/docs/formatting-error-messages
+	cloudErrorEventType = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+)
+
+func getCloudEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		MessageKey: "message",
+		LevelKey:   "severity",
+		TimeKey:    "time",
+		NameKey:    "logger",
+		// the caller is written as sourceLocation by cloudCore.
+		CallerKey:      "",
+		StacktraceKey:  "",
+		EncodeLevel:    cloudSeverityEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	}
+}
+
+// cloudSeverityEncoder encodes levels as LogSeverity names.
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#logseverity
+func cloudSeverityEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
+
+// cloudCore rewrites entries into the shape Cloud Logging and Error Reporting understand.
+type cloudCore struct {
+	zapcore.Core
+	projectID   string
+	serviceName string
+}
+
+func newCloudCore(core zapcore.Core, projectID string, serviceName string) zapcore.Core {
+	return &cloudCore{Core: core, projectID: projectID, serviceName: serviceName}
+}
+
+func (c *cloudCore) With(fields []zapcore.Field) zapcore.Core {
+	return &cloudCore{Core: c.Core.With(c.rewrite(fields)), projectID: c.projectID, serviceName: c.serviceName}
+}
+
+func (c *cloudCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *cloudCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	fields = c.rewrite(fields)
+
+	if ent.Caller.Defined {
+		fields = append(fields, zap.Object(cloudSourceLocationKey, sourceLocation(ent.Caller)))
+	}
+
+	// zap stacks are not in the runtime.Stack format Error Reporting parses, so errors are
+	// reported by the location of the call instead; the stack is kept for readers of the log.
+	// Access entries are not reported: the error handler already reports the failure where
+	// it happened, while they would all group under the access middleware.
+	if ent.Level >= zapcore.ErrorLevel && ent.Caller.Defined && !hasField(fields, cloudHTTPRequestKey) {
+		fields = append(fields,
+			zap.String("@type", cloudErrorEventType),
+			zap.Object("serviceContext", serviceContext(c.serviceName)),
+			zap.Object("context", errorContext(ent.Caller)),
+		)
+
+		if ent.Stack != "" {
+			fields = append(fields, zap.String("stacktrace", ent.Stack))
+		}
+	}
+
+	return c.Core.Write(ent, fields)
+}
+
+// rewrite renames trace fields added by FieldsFromContext to the Cloud Logging keys.
+func (c *cloudCore) rewrite(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, 0, len(fields))
+
+	for _, f := range fields {
+		switch {
+		case f.Key == "traceId" && f.Type == zapcore.StringType:
+			out = append(out, zap.String(cloudTraceKey, fmt.Sprintf("projects/%s/traces/%s", c.projectID, f.String)))
+		case f.Key == "spanId" && f.Type == zapcore.StringType:
+			out = append(out, zap.String(cloudSpanIDKey, f.String))
+		default:
+			out = append(out, f)
+		}
+	}
+
+	return out
+}
+
+func hasField(fields []zapcore.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+type sourceLocation zapcore.EntryCaller
+
+func (s sourceLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("file", s.File)
+	enc.AddString("line", strconv.Itoa(s.Line))
+	enc.AddString("function", s.Function)
+
+	return nil
+}
+
+// errorContext is the ErrorContext of a ReportedErrorEvent.
+// https://cloud.google.com/error-reporting/reference/rest/v1beta1/ErrorContext
+type errorContext zapcore.EntryCaller
+
+func (e errorContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return enc.AddObject("reportLocation", reportLocation(e))
+}
+
+type reportLocation zapcore.EntryCaller
+
+func (r reportLocation) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("filePath", r.File)
+	enc.AddInt("lineNumber", r.Line)
+	enc.AddString("functionName", r.Function)
+
+	return nil
+}
+
+type serviceContext string
+
+func (s serviceContext) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("service", string(s))
+
+	return nil
+}
+
+// httpRequest is the Cloud Logging HttpRequest shape.
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#httprequest
+type httpRequest struct {
+	RequestMethod string
+	RequestURL    string
+	Status        int
+	RequestSize   int
+	ResponseSize  int
+	UserAgent     string
+	RemoteIP      string
+	ServerIP      string
+	Referer       string
+	Latency       time.Duration
+	Protocol      string
+}
+
+func (r httpRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("requestMethod", r.RequestMethod)
+	enc.AddString("requestUrl", r.RequestURL)
+	enc.AddInt("status", r.Status)
+	enc.AddString("requestSize", strconv.Itoa(r.RequestSize))
+	enc.AddString("responseSize", strconv.Itoa(r.ResponseSize))
+	enc.AddString("userAgent", r.UserAgent)
+	enc.AddString("remoteIp", r.RemoteIP)
+	enc.AddString("serverIp", r.ServerIP)
+	enc.AddString("referer", r.Referer)
+	enc.AddString("latency", fmt.Sprintf("%.9fs", r.Latency.Seconds()))
+	enc.AddString("protocol", r.Protocol)
+
+	return nil
+}
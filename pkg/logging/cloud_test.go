@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCloudReportsErrorsButNotAccessEntries(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	l, err := NewContextLogger(Config{Level: "debug", CloudLogging: true}, ContextParser, zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return newCloudCore(core, "project", "service")
+	}))
+	if err != nil {
+		t.Fatalf("NewContextLogger() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(l.Access)
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		l.Error(c, "failed")
+		return c.SendStatus(http.StatusInternalServerError)
+	})
+
+	access := accessEntry(t, app, logs, "/fail")
+
+	errs := logs.FilterMessage("failed").TakeAll()
+	if len(errs) != 1 {
+		t.Fatalf("error entries = %d, want 1", len(errs))
+	}
+
+	fields := errs[0].ContextMap()
+	if fields["@type"] != cloudErrorEventType {
+		t.Fatalf("error entry fields = %v, want a ReportedErrorEvent", fields)
+	}
+
+	location, _ := fields["context"].(map[string]interface{})["reportLocation"].(map[string]interface{})
+	if file, _ := location["filePath"].(string); !strings.HasSuffix(file, "pkg/logging/cloud_test.go") {
+		t.Fatalf("reportLocation = %v, want the caller of Error", location)
+	}
+
+	if fields := access.ContextMap(); access.Level != zapcore.ErrorLevel || fields["@type"] != nil || fields[cloudHTTPRequestKey] == nil {
+		t.Fatalf("access entry = %s %v, want an error with httpRequest and without @type", access.Level, fields)
+	}
+}
@@ -1,9 +1,6 @@
 package logging
 
 import (
-	"context"
-	"fmt"
-
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -16,6 +13,7 @@ type ContextLogger interface {
 	Warn(ctx *fiber.Ctx, msg string, fields ...zapcore.Field)
 	Debug(ctx *fiber.Ctx, msg string, fields ...zapcore.Field)
 	Access(ctx *fiber.Ctx) error
+	SkipAccess(paths ...string) ContextLogger
 	Named(name string) ContextLogger
 	With(fields ...zapcore.Field) ContextLogger
 	Unwrap() *zap.Logger
@@ -26,28 +24,50 @@ type contextParser func(ctx *fiber.Ctx) []zap.Field
 type contextLogger struct {
 	logger        *zap.Logger
 	contextParser contextParser
+
+	// access logs the entries of Access, see newContextLogger.
+	access *zap.Logger
+
+	// skipPaths are request paths Access does not log.
+	skipPaths map[string]struct{}
+
+	// cloud makes Access log the Cloud Logging httpRequest object.
+	cloud bool
 }
 
-func NewContextLogger(logLevel string, contextParser contextParser, opts ...zap.Option) (ContextLogger, error) {
+func NewContextLogger(cfg Config, contextParser contextParser, opts ...zap.Option) (ContextLogger, error) {
 	opts = append(opts, zap.AddCallerSkip(1))
 
-	l, err := newZapLogger(logLevel, opts...)
+	l, err := newZapLogger(cfg, opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &contextLogger{logger: l, contextParser: contextParser}, nil
+	return newContextLogger(l, contextParser, nil, cfg.CloudLogging), nil
 }
 
 func NewDevelopmentContextLogger(contextParser contextParser, opts ...zap.Option) (ContextLogger, error) {
 	opts = append(opts, zap.AddCallerSkip(1))
 
-	l, err := zap.NewDevelopment(opts...)
+	l, err := newDevelopmentZapLogger(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init logger for development: %w", err)
+		return nil, err
 	}
 
-	return &contextLogger{logger: l, contextParser: contextParser}, nil
+	return newContextLogger(l, contextParser, nil, false), nil
+}
+
+// newContextLogger wraps logger. Access checks its entries itself rather than through one of
+// the wrapping methods, so its logger drops the caller skip; it attaches no stack trace either,
+// since the stack would only show the middleware chain.
+func newContextLogger(logger *zap.Logger, parser contextParser, skipPaths map[string]struct{}, cloud bool) *contextLogger {
+	return &contextLogger{
+		logger:        logger,
+		contextParser: parser,
+		access:        logger.WithOptions(zap.AddCallerSkip(-1), zap.AddStacktrace(zapcore.FatalLevel)),
+		skipPaths:     skipPaths,
+		cloud:         cloud,
+	}
 }
 
 func (l *contextLogger) Info(ctx *fiber.Ctx, msg string, fields ...zapcore.Field) {
@@ -75,16 +95,8 @@ func (l *contextLogger) Debug(ctx *fiber.Ctx, msg string, fields ...zapcore.Fiel
 	l.logger.Debug(msg, f...)
 }
 
-var traceIDCtxKey = struct{}{}
-
-func (l *contextLogger) Access(ctx *fiber.Ctx) error {
-	ctx.SetUserContext(context.WithValue(ctx.Context(), traceIDCtxKey, ctx.Get("X-Trace-ID")))
-	l.logger.Info("access", l.parseContext(ctx)...)
-	return ctx.Next()
-}
-
 func (l *contextLogger) Named(name string) ContextLogger {
-	return &contextLogger{logger: l.logger.Named(name), contextParser: l.contextParser}
+	return newContextLogger(l.logger.Named(name), l.contextParser, l.skipPaths, l.cloud)
 }
 
 func (l *contextLogger) Unwrap() *zap.Logger {
@@ -92,7 +104,7 @@ func (l *contextLogger) Unwrap() *zap.Logger {
 }
 
 func (l *contextLogger) With(fields ...zapcore.Field) ContextLogger {
-	return &contextLogger{logger: l.logger.With(fields...), contextParser: l.contextParser}
+	return newContextLogger(l.logger.With(fields...), l.contextParser, l.skipPaths, l.cloud)
 }
 
 func (l *contextLogger) parseContext(ctx *fiber.Ctx) []zapcore.Field {
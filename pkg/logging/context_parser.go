@@ -7,10 +7,12 @@ import (
 )
 
 func ContextParser(c *fiber.Ctx) []zapcore.Field {
-	return []zapcore.Field{
+	fields := []zapcore.Field{
 		zap.String("method", c.Method()),
 		zap.String("localAddr", c.Context().LocalAddr().String()),
 		zap.String("remoteAddr", c.Context().RemoteAddr().String()),
-		zap.String("uri", c.Request().URI().String()),
+		zap.String("uri", RedactURI(c.Request().URI().String())),
 	}
+
+	return append(fields, FieldsFromContext(c.UserContext())...)
 }
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// CtxLogger logs with the fields carried by context.Context.
+// It is meant for code outside fiber handlers, such as repositories, retriers and scrapers.
+type CtxLogger interface {
+	Info(ctx context.Context, msg string, fields ...zapcore.Field)
+	Error(ctx context.Context, msg string, fields ...zapcore.Field)
+	Fatal(ctx context.Context, msg string, fields ...zapcore.Field)
+	Warn(ctx context.Context, msg string, fields ...zapcore.Field)
+	Debug(ctx context.Context, msg string, fields ...zapcore.Field)
+	Named(name string) CtxLogger
+	With(fields ...zapcore.Field) CtxLogger
+	Unwrap() *zap.Logger
+}
+
+type ctxLogger struct {
+	logger *zap.Logger
+}
+
+func NewCtxLogger(cfg Config, opts ...zap.Option) (CtxLogger, error) {
+	opts = append(opts, zap.AddCallerSkip(1))
+
+	l, err := newZapLogger(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ctxLogger{logger: l}, nil
+}
+
+func NewDevelopmentCtxLogger(opts ...zap.Option) (CtxLogger, error) {
+	opts = append(opts, zap.AddCallerSkip(1))
+
+	l, err := newDevelopmentZapLogger(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ctxLogger{logger: l}, nil
+}
+
+func (l *ctxLogger) Info(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.logger.Info(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func (l *ctxLogger) Error(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.logger.Error(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func (l *ctxLogger) Fatal(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.logger.Fatal(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func (l *ctxLogger) Warn(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.logger.Warn(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func (l *ctxLogger) Debug(ctx context.Context, msg string, fields ...zapcore.Field) {
+	l.logger.Debug(msg, append(FieldsFromContext(ctx), fields...)...)
+}
+
+func (l *ctxLogger) Named(name string) CtxLogger {
+	return &ctxLogger{logger: l.logger.Named(name)}
+}
+
+func (l *ctxLogger) With(fields ...zapcore.Field) CtxLogger {
+	return &ctxLogger{logger: l.logger.With(fields...)}
+}
+
+func (l *ctxLogger) Unwrap() *zap.Logger {
+	return l.logger
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDCtxKey, id)
+}
+
+// UserIDFromContext returns the user ID stored in ctx, or an empty string if there is none.
+func UserIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDCtxKey).(string)
+	return id
+}
+
+// WithRoute returns a copy of ctx carrying the route template, such as /v1/parts/:id.
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeCtxKey, &routeRef{route: route})
+}
+
+// RouteFromContext returns the route template stored in ctx, or an empty string if there is none.
+func RouteFromContext(ctx context.Context) string {
+	ref, _ := ctx.Value(routeCtxKey).(*routeRef)
+	if ref == nil {
+		return ""
+	}
+
+	return ref.get()
+}
+
+// routeRef holds the route of a request. Access stores it before the handler has been matched,
+// so it reads the route from the fiber context until Access freezes it once the handler returned.
+type routeRef struct {
+	mu    sync.Mutex
+	ctx   *fiber.Ctx
+	route string
+}
+
+func (r *routeRef) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ctx != nil {
+		return r.ctx.Route().Path
+	}
+
+	return r.route
+}
+
+// freeze stops reading the fiber context, which is reused once the request completes.
+func (r *routeRef) freeze() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ctx != nil {
+		r.route = r.ctx.Route().Path
+		r.ctx = nil
+	}
+}
+
+// detach copies a string read from the request, e.g. a header value: it aliases a buffer
+// fasthttp reuses once the request completes, while the context storing it may outlive it.
+func detach(s string) string {
+	return utils.CopyString(s)
+}
+
+// WithFields returns a copy of ctx carrying additional log fields.
+// Fields already stored in ctx are kept.
+func WithFields(ctx context.Context, fields ...zapcore.Field) context.Context {
+	current, _ := ctx.Value(fieldsCtxKey).([]zapcore.Field)
+
+	// copy so that sibling contexts never share the backing array.
+	merged := make([]zapcore.Field, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, fieldsCtxKey, merged)
+}
+
+// FieldsFromContext returns the log fields carried by ctx:
+// request ID, user ID, route, trace and span IDs and fields added by WithFields.
+func FieldsFromContext(ctx context.Context) []zapcore.Field {
+	fields := []zapcore.Field{}
+
+	if id := RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, zap.String("requestId", id))
+	}
+
+	if id := UserIDFromContext(ctx); id != "" {
+		fields = append(fields, zap.String("userId", id))
+	}
+
+	if route := RouteFromContext(ctx); route != "" {
+		fields = append(fields, zap.String("route", route))
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields,
+			zap.String("traceId", sc.TraceID().String()),
+			zap.String("spanId", sc.SpanID().String()),
+		)
+	}
+
+	extra, _ := ctx.Value(fieldsCtxKey).([]zapcore.Field)
+
+	return append(fields, extra...)
+}
@@ -0,0 +1,280 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelState describes a log level and when it reverts, if it is temporary.
+type LevelState struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// LevelSnapshot describes the global level and the per-named-logger overrides.
+type LevelSnapshot struct {
+	Global LevelState            `json:"global"`
+	Named  map[string]LevelState `json:"named"`
+}
+
+type levelEntry struct {
+	level     zap.AtomicLevel
+	timer     *time.Timer
+	expiresAt time.Time
+
+	// gen is incremented on every change, so that a timer which fired before being
+	// stopped does not revert a newer level.
+	gen uint64
+
+	// previous is the level restored when a temporary global level expires.
+	previous zapcore.Level
+}
+
+// Levels holds the global log level and per-named-logger overrides shared by every logger.
+type Levels struct {
+	mu     sync.Mutex
+	global *levelEntry
+	named  map[string]*levelEntry
+
+	// overrides holds a map[string]zap.AtomicLevel copy of named, replaced on every change,
+	// so that logging does not take mu.
+	overrides atomic.Value
+}
+
+var levels = newLevels()
+
+func newLevels() *Levels {
+	l := &Levels{
+		global: &levelEntry{level: zap.NewAtomicLevel()},
+		named:  map[string]*levelEntry{},
+	}
+	l.publish()
+
+	return l
+}
+
+// GetLevels returns the levels shared by all loggers of this package.
+func GetLevels() *Levels {
+	return levels
+}
+
+// ValidateLevel returns an error if logLevel is not a supported level name.
+func ValidateLevel(logLevel string) error {
+	_, err := getLogLevel(logLevel)
+	return err
+}
+
+// SetGlobal sets the global level. If ttl is positive the previous level is restored after ttl.
+func (l *Levels) SetGlobal(logLevel string, ttl time.Duration) error {
+	lvl, err := getLogLevel(logLevel)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := l.global
+	previous := e.level.Level()
+
+	if e.timer != nil {
+		// keep reverting to the level that was set before the first temporary change.
+		previous = e.previous
+	}
+
+	e.reset()
+	e.level.SetLevel(lvl)
+
+	if ttl > 0 {
+		gen := e.gen
+		e.previous = previous
+		e.expiresAt = time.Now().Add(ttl)
+		e.timer = time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if e.gen != gen {
+				return
+			}
+
+			e.reset()
+			e.level.SetLevel(e.previous)
+		})
+	}
+
+	return nil
+}
+
+// SetNamed overrides the level of the named logger and its children (e.g. "repository" covers "repository.parts").
+// If ttl is positive the override is removed after ttl.
+func (l *Levels) SetNamed(name string, logLevel string, ttl time.Duration) error {
+	if name == "" {
+		return l.SetGlobal(logLevel, ttl)
+	}
+
+	lvl, err := getLogLevel(logLevel)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.named[name]
+	if !ok {
+		e = &levelEntry{level: zap.NewAtomicLevel()}
+		l.named[name] = e
+		l.publish()
+	}
+
+	e.reset()
+	e.level.SetLevel(lvl)
+
+	if ttl > 0 {
+		gen := e.gen
+		e.expiresAt = time.Now().Add(ttl)
+		e.timer = time.AfterFunc(ttl, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			if e.gen != gen || l.named[name] != e {
+				return
+			}
+
+			e.reset()
+			delete(l.named, name)
+			l.publish()
+		})
+	}
+
+	return nil
+}
+
+// ResetNamed removes the override of the named logger.
+func (l *Levels) ResetNamed(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.named[name]; ok {
+		e.reset()
+		delete(l.named, name)
+		l.publish()
+	}
+}
+
+// publish must be called with l.mu held, after named changed.
+func (l *Levels) publish() {
+	overrides := make(map[string]zap.AtomicLevel, len(l.named))
+	for name, e := range l.named {
+		overrides[name] = e.level
+	}
+
+	l.overrides.Store(overrides)
+}
+
+// reset must be called with the Levels lock held. It cancels the pending revert of e.
+func (e *levelEntry) reset() {
+	e.gen++
+
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+
+	e.expiresAt = time.Time{}
+}
+
+// Snapshot returns the current levels.
+func (l *Levels) Snapshot() LevelSnapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s := LevelSnapshot{
+		Global: l.global.state(),
+		Named:  make(map[string]LevelState, len(l.named)),
+	}
+
+	for name, e := range l.named {
+		s.Named[name] = e.state()
+	}
+
+	return s
+}
+
+func (e *levelEntry) state() LevelState {
+	s := LevelState{Level: e.level.Level().String()}
+	if !e.expiresAt.IsZero() {
+		t := e.expiresAt
+		s.ExpiresAt = &t
+	}
+
+	return s
+}
+
+// enabled reports whether lvl is enabled for the named logger, using the most specific override.
+func (l *Levels) enabled(name string, lvl zapcore.Level) bool {
+	overrides := l.overrides.Load().(map[string]zap.AtomicLevel)
+
+	for n := name; n != ""; n = parentName(n) {
+		if level, ok := overrides[n]; ok {
+			return level.Enabled(lvl)
+		}
+	}
+
+	return l.global.level.Enabled(lvl)
+}
+
+// anyEnabled reports whether lvl is enabled for at least one logger.
+func (l *Levels) anyEnabled(lvl zapcore.Level) bool {
+	if l.global.level.Enabled(lvl) {
+		return true
+	}
+
+	for _, level := range l.overrides.Load().(map[string]zap.AtomicLevel) {
+		if level.Enabled(lvl) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parentName(name string) string {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return ""
+	}
+
+	return name[:i]
+}
+
+// levelCore filters entries by the level of the logger name they were written with.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func newLevelCore(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.levels.anyEnabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabled(ent.LoggerName, ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
@@ -1,13 +1,10 @@
 package logging
 
 import (
-	"fmt"
-
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-
 type Logger interface {
 	Info(msg string, fields ...zapcore.Field)
 	Error(msg string, fields ...zapcore.Field)
@@ -24,10 +21,10 @@ type logger struct {
 	logger *zap.Logger
 }
 
-func NewLogger(logLevel string, opts ...zap.Option) (Logger, error) {
+func NewLogger(cfg Config, opts ...zap.Option) (Logger, error) {
 	opts = append(opts, zap.AddCallerSkip(1))
 
-	l, err := newZapLogger(logLevel, opts...)
+	l, err := newZapLogger(cfg, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -38,9 +35,9 @@ func NewLogger(logLevel string, opts ...zap.Option) (Logger, error) {
 func NewDevelopmentLogger(opts ...zap.Option) (Logger, error) {
 	opts = append(opts, zap.AddCallerSkip(1))
 
-	l, err := zap.NewDevelopment(opts...)
+	l, err := newDevelopmentZapLogger(opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to init logger for development: %w", err)
+		return nil, err
 	}
 
 	return &logger{logger: l}, nil
@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are matched case-insensitively against field keys, so "accessToken" is masked too.
+var sensitiveKeys = []string{"password", "token", "email", "authorization", "secret"}
+
+// sensitiveQueryParams are masked in URIs written to logs; code and state come from OAuth callbacks.
+var sensitiveQueryParams = []string{"api_key", "apikey", "key", "token", "access_token", "password", "secret", "code", "state"}
+
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(k, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactURI masks the values of sensitive query parameters in uri.
+func RedactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.RawQuery == "" {
+		return uri
+	}
+
+	q := u.Query()
+	changed := false
+
+	for key := range q {
+		for _, p := range sensitiveQueryParams {
+			if strings.EqualFold(key, p) {
+				q[key] = []string{redacted}
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+
+	return u.String()
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	var out []zapcore.Field
+
+	for i, f := range fields {
+		if !isSensitiveKey(f.Key) {
+			continue
+		}
+
+		if out == nil {
+			out = make([]zapcore.Field, len(fields))
+			copy(out, fields)
+		}
+
+		out[i] = zap.String(f.Key, redacted)
+	}
+
+	if out == nil {
+		return fields
+	}
+
+	return out
+}
+
+// redactCore masks top-level fields with sensitive keys before they are encoded.
+// Fields nested inside objects are not inspected.
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, redactFields(fields))
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/oklog/ulid/v2"
+)
+
+// HeaderRequestID is the header carrying the request ID.
+const HeaderRequestID = "X-Request-ID"
+
+// headerLegacyTraceID is accepted as the request ID for clients still sending it.
+const headerLegacyTraceID = "X-Trace-ID"
+
+// maxRequestIDLen bounds the length of request IDs supplied by clients.
+const maxRequestIDLen = 128
+
+type ctxKey int
+
+const (
+	requestIDCtxKey ctxKey = iota
+	userIDCtxKey
+	routeCtxKey
+	fieldsCtxKey
+)
+
+// RequestID is a fiber middleware assigning a request ID to each request.
+// The ID is taken from the request header, or generated as a ULID when absent or invalid,
+// echoed in the response header and stored in the user context.
+func RequestID(c *fiber.Ctx) error {
+	id := c.Get(HeaderRequestID)
+	if id == "" {
+		id = c.Get(headerLegacyTraceID)
+	}
+
+	if validRequestID(id) {
+		id = detach(id)
+	} else {
+		id = newRequestID()
+	}
+
+	c.Set(HeaderRequestID, id)
+	c.SetUserContext(WithRequestID(c.UserContext(), id))
+
+	return c.Next()
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey).(string)
+	return id
+}
+
+// validRequestID reports whether a client-supplied ID is short and made of [A-Za-z0-9._-],
+// so that it cannot inject content into the logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		switch ch := id[i]; {
+		case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9':
+		case ch == '.' || ch == '_' || ch == '-':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	// crypto/rand.Reader is safe for concurrent use, unlike ulid.Monotonic.
+	return ulid.MustNew(ulid.Timestamp(time.Now()), rand.Reader).String()
+}
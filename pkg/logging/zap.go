@@ -3,42 +3,170 @@ package logging
 import (
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-func newZapLogger(logLevel string, opts ...zap.Option) (*zap.Logger, error) {
-	l, err := getLogLevel(logLevel)
+// Encoding is the log line format.
+type Encoding string
+
+const (
+	EncodingJSON    Encoding = "json"
+	EncodingConsole Encoding = "console"
+)
+
+// Output is the destination of log lines.
+type Output string
+
+const (
+	OutputStdout Output = "stdout"
+	OutputFile   Output = "file"
+	OutputBoth   Output = "both"
+)
+
+var (
+	errUnsupportedEncoding = errors.New("unknown log encoding")
+	errUnsupportedOutput   = errors.New("unknown log output")
+)
+
+// Config configures loggers built by NewLogger, NewContextLogger and NewCtxLogger.
+type Config struct {
+	Level    string
+	Encoding Encoding
+	Output   Output
+
+	// File settings are used when Output is file or both.
+	FilePath       string
+	FileMaxSizeMB  int
+	FileMaxBackups int
+	FileMaxAgeDays int
+
+	// SamplingInitial entries per message per second are logged, then every SamplingThereafter-th.
+	// Sampling is disabled when SamplingInitial is 0.
+	SamplingInitial    int
+	SamplingThereafter int
+
+	// CloudLogging writes JSON in the Google Cloud Logging structured format; Encoding is ignored.
+	CloudLogging   bool
+	CloudProjectID string
+	ServiceName    string
+}
+
+func newZapLogger(cfg Config, opts ...zap.Option) (*zap.Logger, error) {
+	l, err := getLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	levels.global.level.SetLevel(l)
+
+	enc, err := newEncoder(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	level := zap.NewAtomicLevel()
-	level.SetLevel(l)
-
-	config := zap.Config{
-		Level:             level,
-		Development:       false,
-		DisableCaller:     false,
-		DisableStacktrace: false,
-		Sampling:          nil,
-		Encoding:          "json",
-		EncoderConfig:     getDefaultEncoderConfig(),
-		OutputPaths:       []string{"stdout"},
-		ErrorOutputPaths:  []string{"stderr"},
-		InitialFields:     map[string]interface{}{},
+	sink, err := newSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// entries are filtered by levelCore, so the core itself accepts every level.
+	core := zapcore.NewCore(enc, sink, zapcore.DebugLevel)
+	if cfg.CloudLogging {
+		core = newCloudCore(core, cfg.CloudProjectID, cfg.ServiceName)
+	}
+
+	core = newRedactCore(core)
+
+	if cfg.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.SamplingInitial, cfg.SamplingThereafter)
 	}
 
-	logger, err := config.Build(opts...)
+	opts = append([]zap.Option{
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	}, opts...)
+
+	return zap.New(newLevelCore(core), opts...), nil
+}
+
+func newDevelopmentZapLogger(opts ...zap.Option) (*zap.Logger, error) {
+	levels.global.level.SetLevel(zapcore.DebugLevel)
+
+	logger, err := zap.NewDevelopment(append(opts, zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		return newLevelCore(newRedactCore(c))
+	}))...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build a Zap logger: %w", err)
+		return nil, fmt.Errorf("failed to init logger for development: %w", err)
 	}
 
 	return logger, nil
 }
 
+func newEncoder(cfg Config) (zapcore.Encoder, error) {
+	if cfg.CloudLogging {
+		return zapcore.NewJSONEncoder(getCloudEncoderConfig()), nil
+	}
+
+	switch cfg.Encoding {
+	case EncodingJSON, "":
+		return zapcore.NewJSONEncoder(getDefaultEncoderConfig()), nil
+	case EncodingConsole:
+		return zapcore.NewConsoleEncoder(getDefaultEncoderConfig()), nil
+	default:
+		return nil, fmt.Errorf("%s is not supported: %w", cfg.Encoding, errUnsupportedEncoding)
+	}
+}
+
+func newSink(cfg Config) (zapcore.WriteSyncer, error) {
+	stdout := zapcore.Lock(os.Stdout)
+
+	switch cfg.Output {
+	case OutputStdout, "":
+		return stdout, nil
+	case OutputFile:
+		return newFileSink(cfg), nil
+	case OutputBoth:
+		return zapcore.NewMultiWriteSyncer(stdout, newFileSink(cfg)), nil
+	default:
+		return nil, fmt.Errorf("%s is not supported: %w", cfg.Output, errUnsupportedOutput)
+	}
+}
+
+// fileSinks holds the rotating files by path. Every logger writing to a path must share
+// one lumberjack.Logger, as separate instances would rotate the same file concurrently.
+var fileSinks = struct {
+	sync.Mutex
+	m map[string]zapcore.WriteSyncer
+}{m: map[string]zapcore.WriteSyncer{}}
+
+// newFileSink returns the size-based rotating file of cfg.FilePath, created by the first
+// logger writing to it.
+func newFileSink(cfg Config) zapcore.WriteSyncer {
+	fileSinks.Lock()
+	defer fileSinks.Unlock()
+
+	if sink, ok := fileSinks.m[cfg.FilePath]; ok {
+		return sink
+	}
+
+	sink := zapcore.AddSync(&lumberjack.Logger{
+		Filename:   cfg.FilePath,
+		MaxSize:    cfg.FileMaxSizeMB,
+		MaxBackups: cfg.FileMaxBackups,
+		MaxAge:     cfg.FileMaxAgeDays,
+	})
+	fileSinks.m[cfg.FilePath] = sink
+
+	return sink
+}
 
 func getDefaultEncoderConfig() zapcore.EncoderConfig {
 	// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#logseverity
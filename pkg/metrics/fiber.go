@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+// UnmatchedRoute is the route label of requests no route matched.
+const UnmatchedRoute = "unmatched"
+
+// unmatchedLocal is the fiber local SetUnmatched marks requests with.
+const unmatchedLocal = "metrics.unmatched"
+
+// SetUnmatched records that no route matched ctx, for Instrument to label it UnmatchedRoute
+// rather than with the path of the catch-all middleware rendering it.
+func SetUnmatched(ctx *fiber.Ctx) {
+	ctx.Locals(unmatchedLocal, true)
+}
+
+// Instrument is a fiber middleware recording request count, latency and in-flight requests.
+// It must be registered before any middleware that returns errors, since it resolves errors
+// through the app error handler to observe the final status code.
+func Instrument(c *fiber.Ctx) error {
+	start := time.Now()
+	method := c.Method()
+
+	inFlight := httpRequestsInFlight.WithLabelValues(method)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	if err := c.Next(); err != nil {
+		if err := c.App().ErrorHandler(c, err); err != nil {
+			_ = c.SendStatus(fiber.StatusInternalServerError)
+		}
+	}
+
+	// route template (e.g. /v1/parts/:id) keeps the label cardinality bounded.
+	route := c.Route().Path
+	if unmatched, _ := c.Locals(unmatchedLocal).(bool); unmatched {
+		route = UnmatchedRoute
+	}
+
+	status := strconv.Itoa(c.Response().StatusCode())
+
+	httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+	httpRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+
+	return nil
+}
+
+// Handler returns a fiber handler exposing the registry in Prometheus text format.
+func Handler() fiber.Handler {
+	h := fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	return func(c *fiber.Ctx) error {
+		h(c.Context())
+		return nil
+	}
+}
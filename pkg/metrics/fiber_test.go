@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentRouteLabel(t *testing.T) {
+	app := fiber.New()
+	app.Use(Instrument)
+	app.Get("/parts/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusNoContent)
+	})
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		SetUnmatched(c)
+
+		return err
+	})
+
+	for _, tt := range []struct {
+		method, target, route, status string
+	}{
+		{method: http.MethodGet, target: "/parts/42", route: "/parts/:id", status: "204"},
+		{method: http.MethodGet, target: "/missing/42", route: UnmatchedRoute, status: "404"},
+		{method: http.MethodPost, target: "/parts/42", route: UnmatchedRoute, status: "405"},
+	} {
+		c := httpRequestsTotal.WithLabelValues(tt.method, tt.route, tt.status)
+		before := testutil.ToFloat64(c)
+
+		if _, err := app.Test(httptest.NewRequest(tt.method, tt.target, nil)); err != nil {
+			t.Fatalf("Test() error = %v", err)
+		}
+
+		if got := testutil.ToFloat64(c) - before; got != 1 {
+			t.Errorf("%s %s counted %v times with route %q and status %s, want once", tt.method, tt.target, got, tt.route, tt.status)
+		}
+	}
+}
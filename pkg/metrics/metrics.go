@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+)
+
+const namespace = "kbpartpicker"
+
+// outcome label values.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// the route template is only known once the router has matched, so in-flight requests are labelled by method.
+	httpRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	}, []string{"method"})
+
+	retryAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "retry",
+		Name:      "attempts_total",
+		Help:      "Total number of failed attempts reported by retriers.",
+	}, []string{"name"})
+
+	breakerTransitionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "circuit_breaker",
+		Name:      "transitions_total",
+		Help:      "Total number of circuit breaker state changes.",
+	}, []string{"name", "from", "to"})
+
+	closerResultsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "closer",
+		Name:      "results_total",
+		Help:      "Total number of closer invocations by outcome.",
+	}, []string{"closer", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpRequestsInFlight,
+		retryAttemptsTotal,
+		breakerTransitionsTotal,
+		closerResultsTotal,
+	)
+}
+
+// Registry returns the registry all application metrics are registered to.
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// RetryHook returns a hook for retry.WithHook that counts failed attempts of the named retrier.
+func RetryHook(name string) retry.Hook {
+	c := retryAttemptsTotal.WithLabelValues(name)
+
+	return func(retry.Attempt) {
+		c.Inc()
+	}
+}
+
+// ObserveBreakerTransition records a circuit breaker state change. It is a retry.StateChangeFunc,
+// e.g. retry.OnStateChange(metrics.ObserveBreakerTransition).
+func ObserveBreakerTransition(name string, from, to retry.State) {
+	breakerTransitionsTotal.WithLabelValues(name, from.String(), to.String()).Inc()
+}
+
+// ObserveClose records the outcome of a closer invocation.
+func ObserveClose(closer string, err error) {
+	closerResultsTotal.WithLabelValues(closer, outcome(err)).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeFailure
+	}
+
+	return OutcomeSuccess
+}
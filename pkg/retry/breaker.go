@@ -0,0 +1,421 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the function while the circuit is open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// StateClosed lets every call through and counts failures.
+	StateClosed State = iota
+	// StateOpen rejects every call until the cool-down has elapsed.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// StateChangeFunc is called, outside the breaker lock, when the breaker changes state.
+type StateChangeFunc func(name string, from, to State)
+
+// windowBuckets is the number of buckets the rolling window is divided into.
+const windowBuckets = 10
+
+type bucket struct {
+	// start is the start time of the bucket, in window bucket units.
+	start     int64
+	successes int
+	failures  int
+}
+
+// CircuitBreaker stops calling a failing dependency: it opens when the failure ratio
+// over a rolling window exceeds a threshold, rejects calls during a cool-down, then
+// lets a few probes through and closes again once they all succeed.
+type CircuitBreaker struct {
+	name string
+
+	// window is the duration over which the failure ratio is computed.
+	window time.Duration
+
+	// failureRatio opens the circuit when reached.
+	failureRatio float64
+
+	// minRequests is the number of calls in the window needed before the ratio is considered.
+	minRequests int
+
+	// coolDown is the time the circuit stays open before probing.
+	coolDown time.Duration
+
+	// halfOpenProbes is the number of probe calls allowed in half-open state.
+	halfOpenProbes int
+
+	// failureIf reports whether an error counts as a failure of the dependency.
+	failureIf func(error) bool
+
+	clock          Clock
+	onStateChange  []StateChangeFunc
+	mu             sync.Mutex
+	state          State
+	buckets        [windowBuckets]bucket
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+
+	// generation changes with every state change, so that results of calls admitted
+	// before it are not counted, even when the breaker is back to the same state.
+	generation uint64
+}
+
+// BreakerOption configures a CircuitBreaker.
+type BreakerOption func(*CircuitBreaker) error
+
+var defaultBreakerOptions = []BreakerOption{
+	WithWindow(time.Minute),
+	WithFailureRatio(0.5),
+	WithMinRequests(10),
+	WithCoolDown(30 * time.Second),
+	WithHalfOpenProbes(1),
+	WithFailureIf(isNotPermanent),
+}
+
+func isNotPermanent(err error) bool {
+	return !IsPermanent(err)
+}
+
+// WithWindow sets the rolling window duration.
+func WithWindow(d time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if d < windowBuckets {
+			return fmt.Errorf("failed to set breaker.window: %s", d)
+		}
+
+		b.window = d
+
+		return nil
+	}
+}
+
+// WithFailureRatio sets the failure ratio, in (0, 1], opening the circuit.
+func WithFailureRatio(f float64) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if f <= 0 || f > 1 {
+			return fmt.Errorf("failed to set breaker.failureRatio: %v", f)
+		}
+
+		b.failureRatio = f
+
+		return nil
+	}
+}
+
+// WithMinRequests sets the number of calls in the window needed to open the circuit.
+func WithMinRequests(n int) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if n <= 0 {
+			return fmt.Errorf("failed to set breaker.minRequests: %d", n)
+		}
+
+		b.minRequests = n
+
+		return nil
+	}
+}
+
+// WithCoolDown sets the time the circuit stays open.
+func WithCoolDown(d time.Duration) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if d <= 0 {
+			return fmt.Errorf("failed to set breaker.coolDown: %s", d)
+		}
+
+		b.coolDown = d
+
+		return nil
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls allowed in half-open state.
+func WithHalfOpenProbes(n int) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if n <= 0 {
+			return fmt.Errorf("failed to set breaker.halfOpenProbes: %d", n)
+		}
+
+		b.halfOpenProbes = n
+
+		return nil
+	}
+}
+
+// WithFailureIf sets the classifier of errors counting as failures, e.g. IsRetryableHTTP so that
+// a healthy vendor answering 404 does not open the circuit. Other errors neither count as failures
+// nor as successes. By default every error but permanent ones count. Errors of calls whose context
+// is done are never counted, as they are caused by the caller.
+func WithFailureIf(fn func(err error) bool) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if fn == nil {
+			return errors.New("failed to set breaker.failureIf")
+		}
+
+		b.failureIf = fn
+
+		return nil
+	}
+}
+
+// WithBreakerClock sets the clock of the breaker.
+func WithBreakerClock(c Clock) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if c == nil {
+			return errors.New("failed to set breaker.clock")
+		}
+
+		b.clock = c
+
+		return nil
+	}
+}
+
+// OnStateChange registers fn, e.g. to log or record metrics on state changes.
+func OnStateChange(fn StateChangeFunc) BreakerOption {
+	return func(b *CircuitBreaker) error {
+		if fn == nil {
+			return errors.New("failed to set breaker.onStateChange")
+		}
+
+		b.onStateChange = append(b.onStateChange, fn)
+
+		return nil
+	}
+}
+
+// NewCircuitBreaker returns a closed circuit breaker.
+func NewCircuitBreaker(name string, opts ...BreakerOption) (*CircuitBreaker, error) {
+	b := &CircuitBreaker{name: name, clock: realClock{}}
+
+	for _, opt := range append(defaultBreakerOptions, opts...) {
+		if err := opt(b); err != nil {
+			return nil, fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	return b, nil
+}
+
+// Name returns the name of the breaker.
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// State returns the current state, moving from open to half-open once the cool-down has elapsed.
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	from, to := b.refresh()
+	state := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+
+	return state
+}
+
+// Execute calls fn unless the circuit is open, and records its result.
+// A panic of fn is recorded as a failure before it propagates.
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	done, err := b.allow()
+	if err != nil {
+		return err
+	}
+
+	returned := false
+
+	defer func() {
+		switch {
+		case !returned:
+			done(outcomeFailure)
+		case err == nil:
+			done(outcomeSuccess)
+		case ctx.Err() != nil || !b.failureIf(err):
+			done(outcomeIgnored)
+		default:
+			done(outcomeFailure)
+		}
+	}()
+
+	err = fn(ctx)
+	returned = true
+
+	return err
+}
+
+// outcome is the result of a call, as counted by the breaker.
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	outcomeIgnored
+)
+
+// allow reserves a call, returning the function recording its result.
+func (b *CircuitBreaker) allow() (func(outcome), error) {
+	b.mu.Lock()
+	from, to := b.refresh()
+
+	var err error
+
+	switch b.state {
+	case StateOpen:
+		err = fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+	case StateHalfOpen:
+		if b.probesInFlight+b.probeSuccesses >= b.halfOpenProbes {
+			err = fmt.Errorf("%s: probes in flight: %w", b.name, ErrCircuitOpen)
+		} else {
+			b.probesInFlight++
+		}
+	}
+
+	generation := b.generation
+	b.mu.Unlock()
+
+	b.notify(from, to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func(o outcome) {
+		b.record(generation, o)
+	}, nil
+}
+
+func (b *CircuitBreaker) record(generation uint64, o outcome) {
+	b.mu.Lock()
+
+	from := b.state
+
+	switch {
+	case generation != b.generation:
+		// admitted before a state change: the result tells nothing about the current state.
+	case b.state == StateHalfOpen:
+		// an ignored result frees the probe slot for another probe.
+		b.probesInFlight--
+
+		switch o {
+		case outcomeFailure:
+			b.open()
+		case outcomeSuccess:
+			if b.probeSuccesses++; b.probeSuccesses >= b.halfOpenProbes {
+				b.close()
+			}
+		}
+	case b.state == StateClosed && o != outcomeIgnored:
+		b.count(o == outcomeFailure)
+
+		if b.tripped() {
+			b.open()
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// refresh must be called with b.mu held. It returns the transition it made, if any.
+func (b *CircuitBreaker) refresh() (State, State) {
+	from := b.state
+
+	if b.state == StateOpen && !b.clock.Now().Before(b.openedAt.Add(b.coolDown)) {
+		b.state = StateHalfOpen
+		b.generation++
+		b.probesInFlight = 0
+		b.probeSuccesses = 0
+	}
+
+	return from, b.state
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = StateOpen
+	b.generation++
+	b.openedAt = b.clock.Now()
+}
+
+func (b *CircuitBreaker) close() {
+	b.state = StateClosed
+	b.generation++
+	b.buckets = [windowBuckets]bucket{}
+}
+
+// count records a call result in the bucket of the current time.
+func (b *CircuitBreaker) count(failed bool) {
+	now := b.bucketTime(b.clock.Now())
+	bk := &b.buckets[now%windowBuckets]
+
+	if bk.start != now {
+		*bk = bucket{start: now}
+	}
+
+	if failed {
+		bk.failures++
+	} else {
+		bk.successes++
+	}
+}
+
+// tripped reports whether the failure ratio over the window opens the circuit.
+func (b *CircuitBreaker) tripped() bool {
+	now := b.bucketTime(b.clock.Now())
+
+	var total, failures int
+
+	for _, bk := range b.buckets {
+		if now-bk.start >= windowBuckets {
+			continue
+		}
+
+		total += bk.successes + bk.failures
+		failures += bk.failures
+	}
+
+	return total >= b.minRequests && float64(failures)/float64(total) >= b.failureRatio
+}
+
+func (b *CircuitBreaker) bucketTime(t time.Time) int64 {
+	return t.UnixNano() / int64(b.window/windowBuckets)
+}
+
+func (b *CircuitBreaker) notify(from, to State) {
+	if from == to {
+		return
+	}
+
+	for _, fn := range b.onStateChange {
+		fn(b.name, from, to)
+	}
+}
@@ -0,0 +1,426 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/metrics"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry/retrytest"
+)
+
+var errDown = errors.New("down")
+
+type transition struct {
+	from, to retry.State
+}
+
+type breakerFixture struct {
+	t     *testing.T
+	clock *retrytest.Clock
+	b     *retry.CircuitBreaker
+
+	mu          sync.Mutex
+	transitions []transition
+}
+
+func newBreaker(t *testing.T, opts ...retry.BreakerOption) *breakerFixture {
+	t.Helper()
+
+	f := &breakerFixture{t: t, clock: retrytest.NewClock(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))}
+
+	opts = append([]retry.BreakerOption{
+		retry.WithBreakerClock(f.clock),
+		retry.WithWindow(10 * time.Second),
+		retry.WithFailureRatio(0.5),
+		retry.WithMinRequests(4),
+		retry.WithCoolDown(5 * time.Second),
+		retry.OnStateChange(func(_ string, from, to retry.State) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			f.transitions = append(f.transitions, transition{from, to})
+		}),
+	}, opts...)
+
+	b, err := retry.NewCircuitBreaker("vendor", opts...)
+	if err != nil {
+		t.Fatalf("NewCircuitBreaker() error = %v", err)
+	}
+
+	f.b = b
+
+	return f
+}
+
+// call executes a call returning err and fails the test if the breaker rejected it.
+func (f *breakerFixture) call(err error) {
+	f.t.Helper()
+
+	called := false
+
+	got := f.b.Execute(context.Background(), func(context.Context) error {
+		called = true
+		return err
+	})
+
+	if !called {
+		f.t.Fatalf("Execute() rejected the call: %v", got)
+	}
+}
+
+func (f *breakerFixture) assertState(want retry.State) {
+	f.t.Helper()
+
+	if got := f.b.State(); got != want {
+		f.t.Fatalf("State() = %s, want %s", got, want)
+	}
+}
+
+func (f *breakerFixture) assertRejects() {
+	f.t.Helper()
+
+	err := f.b.Execute(context.Background(), func(context.Context) error {
+		f.t.Fatal("Execute() called fn while the circuit is open")
+		return nil
+	})
+
+	if !errors.Is(err, retry.ErrCircuitOpen) {
+		f.t.Fatalf("Execute() error = %v, want ErrCircuitOpen", err)
+	}
+}
+
+// open trips the breaker.
+func (f *breakerFixture) open() {
+	f.t.Helper()
+
+	for i := 0; i < 4; i++ {
+		f.call(errDown)
+	}
+
+	f.assertState(retry.StateOpen)
+}
+
+func TestBreakerTripsOnFailureRatio(t *testing.T) {
+	f := newBreaker(t)
+
+	f.call(nil)
+	f.call(errDown)
+	f.call(errDown)
+	f.assertState(retry.StateClosed)
+
+	// 2 failures out of 4 calls reach the 0.5 ratio.
+	f.call(nil)
+	f.assertState(retry.StateOpen)
+	f.assertRejects()
+}
+
+func TestBreakerStaysClosedBelowRatio(t *testing.T) {
+	f := newBreaker(t)
+
+	for i := 0; i < 10; i++ {
+		f.call(nil)
+	}
+
+	for i := 0; i < 9; i++ {
+		f.call(errDown)
+	}
+
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerMinRequests(t *testing.T) {
+	f := newBreaker(t, retry.WithMinRequests(5))
+
+	for i := 0; i < 4; i++ {
+		f.call(errDown)
+	}
+
+	f.assertState(retry.StateClosed)
+
+	f.call(errDown)
+	f.assertState(retry.StateOpen)
+}
+
+func TestBreakerForgetsResultsOutsideWindow(t *testing.T) {
+	f := newBreaker(t)
+
+	for i := 0; i < 3; i++ {
+		f.call(errDown)
+	}
+
+	f.clock.Advance(10 * time.Second)
+
+	// the earlier failures have left the window, so the total is below minRequests.
+	f.call(errDown)
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerHalfOpensAfterCoolDown(t *testing.T) {
+	f := newBreaker(t)
+	f.open()
+
+	f.clock.Advance(5*time.Second - time.Nanosecond)
+	f.assertState(retry.StateOpen)
+	f.assertRejects()
+
+	f.clock.Advance(time.Nanosecond)
+	f.assertState(retry.StateHalfOpen)
+
+	f.call(nil)
+	f.assertState(retry.StateClosed)
+
+	want := []transition{
+		{retry.StateClosed, retry.StateOpen},
+		{retry.StateOpen, retry.StateHalfOpen},
+		{retry.StateHalfOpen, retry.StateClosed},
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", f.transitions, want)
+	}
+
+	for i := range want {
+		if f.transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", f.transitions, want)
+		}
+	}
+}
+
+func TestBreakerLimitsProbes(t *testing.T) {
+	f := newBreaker(t, retry.WithHalfOpenProbes(2))
+	f.open()
+	f.clock.Advance(5 * time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_ = f.b.Execute(context.Background(), func(context.Context) error {
+				started <- struct{}{}
+				<-release
+
+				return nil
+			})
+		}()
+	}
+
+	<-started
+	<-started
+
+	// both probe slots are taken.
+	f.assertRejects()
+
+	close(release)
+	wg.Wait()
+
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerReopensOnProbeFailure(t *testing.T) {
+	f := newBreaker(t, retry.WithHalfOpenProbes(2))
+	f.open()
+	f.clock.Advance(5 * time.Second)
+
+	f.call(nil)
+	f.assertState(retry.StateHalfOpen)
+
+	f.call(errDown)
+	f.assertState(retry.StateOpen)
+
+	// the cool-down starts over from the failed probe.
+	f.clock.Advance(5*time.Second - time.Nanosecond)
+	f.assertState(retry.StateOpen)
+
+	f.clock.Advance(time.Nanosecond)
+	f.assertState(retry.StateHalfOpen)
+}
+
+func TestBreakerIgnoresPermanentAndCanceledErrors(t *testing.T) {
+	f := newBreaker(t)
+
+	for i := 0; i < 4; i++ {
+		f.call(retry.Permanent(errDown))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 4; i++ {
+		_ = f.b.Execute(ctx, func(ctx context.Context) error {
+			return ctx.Err()
+		})
+	}
+
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerFailureIf(t *testing.T) {
+	notFound := errors.New("not found")
+
+	f := newBreaker(t, retry.WithFailureIf(func(err error) bool {
+		return !errors.Is(err, notFound)
+	}))
+
+	for i := 0; i < 4; i++ {
+		f.call(notFound)
+	}
+
+	f.assertState(retry.StateClosed)
+
+	f.open()
+}
+
+func TestBreakerIgnoredProbeFreesSlot(t *testing.T) {
+	f := newBreaker(t)
+	f.open()
+	f.clock.Advance(5 * time.Second)
+
+	f.call(retry.Permanent(errDown))
+	f.assertState(retry.StateHalfOpen)
+
+	f.call(nil)
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerRecordsPanicAsFailure(t *testing.T) {
+	f := newBreaker(t)
+	f.open()
+	f.clock.Advance(5 * time.Second)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("Execute() swallowed the panic")
+			}
+		}()
+
+		_ = f.b.Execute(context.Background(), func(context.Context) error {
+			panic("boom")
+		})
+	}()
+
+	// the probe slot was released and the failure reopened the circuit.
+	f.assertState(retry.StateOpen)
+
+	f.clock.Advance(5 * time.Second)
+	f.call(nil)
+	f.assertState(retry.StateClosed)
+}
+
+func TestRetrierFailsFastWhenOpen(t *testing.T) {
+	f := newBreaker(t)
+	f.open()
+
+	calls := 0
+
+	r, err := retry.New(retry.WithCircuitBreaker(f.b), retry.WithClock(f.clock))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	err = r.Do(context.Background(), func(context.Context) error {
+		calls++
+		return nil
+	})
+
+	if !errors.Is(err, retry.ErrCircuitOpen) {
+		t.Fatalf("Do() error = %v, want ErrCircuitOpen", err)
+	}
+
+	if calls != 0 {
+		t.Fatalf("fn called %d times, want 0", calls)
+	}
+}
+
+func TestBreakerIgnoresProbesOfEarlierHalfOpenPeriods(t *testing.T) {
+	f := newBreaker(t, retry.WithHalfOpenProbes(2))
+	f.open()
+	f.clock.Advance(5 * time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_ = f.b.Execute(context.Background(), func(context.Context) error {
+			close(started)
+			<-release
+
+			return nil
+		})
+	}()
+
+	<-started
+
+	// the other probe fails, and the breaker half-opens again while the first one runs.
+	f.call(errDown)
+	f.assertState(retry.StateOpen)
+	f.clock.Advance(5 * time.Second)
+	f.assertState(retry.StateHalfOpen)
+
+	close(release)
+	<-done
+
+	// the late success belongs to the previous period: two new successes are needed to close.
+	f.call(nil)
+	f.assertState(retry.StateHalfOpen)
+
+	f.call(nil)
+	f.assertState(retry.StateClosed)
+}
+
+func TestBreakerTransitionMetrics(t *testing.T) {
+	before := transitionsTotal(t, "closed", "open")
+
+	f := newBreaker(t, retry.OnStateChange(metrics.ObserveBreakerTransition))
+	f.open()
+
+	if got := transitionsTotal(t, "closed", "open") - before; got != 1 {
+		t.Fatalf("closed to open transitions = %v, want 1", got)
+	}
+}
+
+// transitionsTotal returns the recorded transitions of the "vendor" breaker from and to the given states.
+func transitionsTotal(t *testing.T, from, to string) float64 {
+	t.Helper()
+
+	families, err := metrics.Registry().Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "kbpartpicker_circuit_breaker_transitions_total" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["name"] == "vendor" && labels["from"] == from && labels["to"] == to {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,59 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBudgetExhausted is returned instead of retrying when the retry budget is spent.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Budget limits retries to a ratio of calls, so that retriers sharing it cannot
+// multiply the load on a failing dependency. It is a token bucket: every call
+// deposits ratio tokens and every retry withdraws one.
+type Budget struct {
+	mu        sync.Mutex
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewBudget returns a budget allowing retries for ratio of the calls, e.g. 0.1 for 10%.
+// maxTokens bounds the burst of retries after a quiet period; the budget starts full.
+func NewBudget(ratio, maxTokens float64) (*Budget, error) {
+	if ratio <= 0 {
+		return nil, fmt.Errorf("failed to set budget.ratio: %v", ratio)
+	}
+
+	if maxTokens < 1 {
+		return nil, fmt.Errorf("failed to set budget.maxTokens: %v", maxTokens)
+	}
+
+	return &Budget{ratio: ratio, maxTokens: maxTokens, tokens: maxTokens}, nil
+}
+
+// deposit records a call.
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.ratio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// withdraw reserves a retry, reporting false when the budget is spent.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
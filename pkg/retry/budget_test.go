@@ -0,0 +1,118 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+)
+
+func TestNewBudgetRejects(t *testing.T) {
+	for _, tt := range []struct {
+		ratio, maxTokens float64
+	}{
+		{ratio: 0, maxTokens: 10},
+		{ratio: -0.1, maxTokens: 10},
+		{ratio: 0.1, maxTokens: 0.5},
+	} {
+		if _, err := retry.NewBudget(tt.ratio, tt.maxTokens); err == nil {
+			t.Errorf("NewBudget(%v, %v) succeeded", tt.ratio, tt.maxTokens)
+		}
+	}
+}
+
+func TestDoWithBudget(t *testing.T) {
+	budget, err := retry.NewBudget(0.5, 2)
+	if err != nil {
+		t.Fatalf("NewBudget() error = %v", err)
+	}
+
+	clock := newClock()
+	opts := []retry.Option{retry.WithBudget(budget), retry.WithRetryCnt(10)}
+
+	// do runs a failing call, returning its number of attempts and its error.
+	do := func() (int, error) {
+		calls := 0
+		_, err := run(t, clock, failing(&calls), opts...)
+
+		return calls, err
+	}
+
+	succeed := func() {
+		if _, err := run(t, clock, func(context.Context) error { return nil }, opts...); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		successes int
+		want      int
+	}{
+		// the budget starts full; the deposit of the call is capped by maxTokens.
+		{name: "full", want: 3},
+		// the deposit of the call alone is half a retry.
+		{name: "exhausted", want: 1},
+		// the successful call and the failing one deposit a retry together.
+		{name: "refilled by calls", successes: 1, want: 2},
+		// successful calls refill up to maxTokens only.
+		{name: "refilled to the max", successes: 10, want: 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.successes; i++ {
+				succeed()
+			}
+
+			calls, err := do()
+			if calls != tt.want || !errors.Is(err, retry.ErrBudgetExhausted) {
+				t.Fatalf("Do() = %d calls, %v, want %d calls and %v", calls, err, tt.want, retry.ErrBudgetExhausted)
+			}
+		})
+	}
+}
+
+func TestBudgetIsShared(t *testing.T) {
+	budget, err := retry.NewBudget(0.1, 1)
+	if err != nil {
+		t.Fatalf("NewBudget() error = %v", err)
+	}
+
+	clock := newClock()
+
+	var first, second int
+
+	// the first retrier spends the only retry, so the second one cannot retry.
+	if _, err := run(t, clock, failing(&first), retry.WithBudget(budget)); !errors.Is(err, retry.ErrBudgetExhausted) || first != 2 {
+		t.Fatalf("first Do() = %d calls, %v, want 2 calls and %v", first, err, retry.ErrBudgetExhausted)
+	}
+
+	if _, err := run(t, clock, failing(&second), retry.WithBudget(budget)); !errors.Is(err, retry.ErrBudgetExhausted) || second != 1 {
+		t.Fatalf("second Do() = %d calls, %v, want 1 call and %v", second, err, retry.ErrBudgetExhausted)
+	}
+}
+
+func TestBudgetDoesNotLimitPermanentErrors(t *testing.T) {
+	budget, err := retry.NewBudget(0.1, 1)
+	if err != nil {
+		t.Fatalf("NewBudget() error = %v", err)
+	}
+
+	calls := 0
+
+	// a permanent error does not withdraw, leaving the retry for the next call.
+	for i := 0; i < 3; i++ {
+		_, err = run(t, newClock(), func(context.Context) error {
+			calls++
+			return retry.Permanent(errFail)
+		}, retry.WithBudget(budget))
+		if errors.Is(err, retry.ErrBudgetExhausted) {
+			t.Fatalf("Do() error = %v, want the permanent error", err)
+		}
+	}
+
+	calls = 0
+	if _, err := run(t, newClock(), failing(&calls), retry.WithBudget(budget)); calls != 2 {
+		t.Fatalf("Do() = %d calls, %v, want the retry left in the budget", calls, err)
+	}
+}
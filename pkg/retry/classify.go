@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// mongo error labels marking operations that are safe to retry.
+var retryableMongoLabels = []string{
+	"RetryableWriteError",
+	"TransientTransactionError",
+	"ResumableChangeStreamError",
+}
+
+// IsRetryableMongo classifies Mongo errors for WithRetryIf: network errors, timeouts,
+// server selection failures and errors labelled retryable by the server are retried.
+func IsRetryableMongo(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) {
+		return true
+	}
+
+	var sse topology.ServerSelectionError
+	if errors.As(err, &sse) || errors.Is(err, topology.ErrServerSelectionTimeout) {
+		return true
+	}
+
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		for _, label := range retryableMongoLabels {
+			if se.HasErrorLabel(label) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HTTPError is an unexpected HTTP response status.
+type HTTPError struct {
+	StatusCode int
+	Method     string
+	URL        string
+
+	// RetryAfter is the delay requested by the Retry-After header, or zero.
+	RetryAfter time.Duration
+}
+
+// CheckResponse returns an *HTTPError when resp does not have a 2xx status.
+// Do honors the Retry-After header of the response through the error.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	e := &HTTPError{StatusCode: resp.StatusCode}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.Redacted()
+	}
+
+	if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		e.RetryAfter = d
+	}
+
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	return e.Method + " " + e.URL + ": " + http.StatusText(e.StatusCode)
+}
+
+// RetryDelay implements Delayer.
+func (e *HTTPError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// IsRetryableHTTP classifies HTTP client errors for WithRetryIf: 5xx, 429 and 408
+// responses, timeouts and dropped connections are retried.
+func IsRetryableHTTP(err error) bool {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he.StatusCode >= http.StatusInternalServerError && he.StatusCode != http.StatusNotImplemented ||
+			he.StatusCode == http.StatusTooManyRequests ||
+			he.StatusCode == http.StatusRequestTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func TestIsRetryableHTTP(t *testing.T) {
+	status := func(code int) error {
+		return &retry.HTTPError{StatusCode: code, Method: http.MethodGet, URL: "https://example.com"}
+	}
+
+	timeout := &net.DNSError{Err: "timeout", IsTimeout: true}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "500", err: status(http.StatusInternalServerError), want: true},
+		{name: "501", err: status(http.StatusNotImplemented), want: false},
+		{name: "502", err: status(http.StatusBadGateway), want: true},
+		{name: "503", err: status(http.StatusServiceUnavailable), want: true},
+		{name: "504", err: status(http.StatusGatewayTimeout), want: true},
+		{name: "429", err: status(http.StatusTooManyRequests), want: true},
+		{name: "408", err: status(http.StatusRequestTimeout), want: true},
+		{name: "400", err: status(http.StatusBadRequest), want: false},
+		{name: "404", err: status(http.StatusNotFound), want: false},
+		{name: "409", err: status(http.StatusConflict), want: false},
+		{name: "wrapped 503", err: fmt.Errorf("fetch: %w", status(http.StatusServiceUnavailable)), want: true},
+		{name: "net timeout", err: timeout, want: true},
+		{name: "client timeout", err: &url.Error{Op: "Get", URL: "https://example.com", Err: timeout}, want: true},
+		{name: "net error without timeout", err: &net.DNSError{Err: "no such host", IsNotFound: true}, want: false},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, want: true},
+		{name: "connection reset", err: &url.Error{Op: "Get", URL: "https://example.com", Err: syscall.ECONNRESET}, want: true},
+		{name: "broken pipe", err: syscall.EPIPE, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "EOF", err: io.EOF, want: true},
+		// an attempt timeout ends the attempt with its context error.
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "other", err: errors.New("invalid json"), want: false},
+		{name: "nil", err: nil, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry.IsRetryableHTTP(tt.err); got != tt.want {
+				t.Fatalf("IsRetryableHTTP(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryableMongo(t *testing.T) {
+	labelled := func(labels ...string) error {
+		return mongo.CommandError{Code: 1, Message: "failed", Labels: labels}
+	}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "network error", err: labelled("NetworkError"), want: true},
+		{name: "network timeout", err: labelled("NetworkTimeoutError"), want: true},
+		{name: "retryable write", err: labelled("RetryableWriteError"), want: true},
+		{name: "transient transaction", err: labelled("TransientTransactionError"), want: true},
+		{name: "resumable change stream", err: labelled("ResumableChangeStreamError"), want: true},
+		{name: "unlabelled command error", err: labelled(), want: false},
+		{
+			name: "retryable write exception",
+			err:  mongo.WriteException{Labels: []string{"RetryableWriteError"}},
+			want: true,
+		},
+		{
+			name: "duplicate key",
+			err:  mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}},
+			want: false,
+		},
+		{name: "server selection", err: topology.ServerSelectionError{Wrapped: errors.New("no primary")}, want: true},
+		{name: "server selection timeout", err: fmt.Errorf("ping: %w", topology.ErrServerSelectionTimeout), want: true},
+		{name: "deadline exceeded", err: fmt.Errorf("find: %w", context.DeadlineExceeded), want: true},
+		{name: "net timeout", err: &net.DNSError{Err: "timeout", IsTimeout: true}, want: true},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "no documents", err: mongo.ErrNoDocuments, want: false},
+		{name: "nil", err: nil, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry.IsRetryableMongo(tt.err); got != tt.want {
+				t.Fatalf("IsRetryableMongo(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
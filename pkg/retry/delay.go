@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delayer is implemented by errors suggesting how long to wait before the next attempt,
+// e.g. from a Retry-After header.
+type Delayer interface {
+	RetryDelay() time.Duration
+}
+
+// DelayError carries a delay suggested by the server.
+type DelayError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// WithDelay wraps err so that Do waits d, instead of the computed backoff, before the next attempt.
+// It returns nil if err is nil.
+func WithDelay(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return &DelayError{Err: err, Delay: d}
+}
+
+func (e *DelayError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DelayError) Unwrap() error {
+	return e.Err
+}
+
+// RetryDelay implements Delayer.
+func (e *DelayError) RetryDelay() time.Duration {
+	return e.Delay
+}
+
+// suggestedDelay returns the delay suggested by err, if any.
+func suggestedDelay(err error) (time.Duration, bool) {
+	var d Delayer
+	if !errors.As(err, &d) {
+		return 0, false
+	}
+
+	delay := d.RetryDelay()
+	if delay <= 0 {
+		return 0, false
+	}
+
+	return delay, true
+}
+
+// ParseRetryAfter parses a Retry-After header value, given in seconds or as an HTTP-date.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if n < 0 {
+			return 0, false
+		}
+
+		// larger values would overflow; Do bounds the delay by the backoff timeout anyway.
+		if n > math.MaxInt64/int64(time.Second) {
+			return math.MaxInt64, true
+		}
+
+		return time.Duration(n) * time.Second, true
+	}
+
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	d := t.Sub(now)
+	if d < 0 {
+		d = 0
+	}
+
+	return d, true
+}
@@ -0,0 +1,86 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// HedgeOption configures Hedge.
+type HedgeOption func(*hedge) error
+
+type hedge struct {
+	clock Clock
+}
+
+// HedgeWithClock sets the clock timing the second call, e.g. a retrytest.Clock.
+func HedgeWithClock(c Clock) HedgeOption {
+	return func(h *hedge) error {
+		if c == nil {
+			return errors.New("failed to set hedge.clock")
+		}
+
+		h.clock = c
+
+		return nil
+	}
+}
+
+// Hedge calls fn, and calls it a second time if the first call has not returned after delay,
+// e.g. for endpoints that occasionally hang. The first success is returned and the other call
+// is cancelled. A call failing while the other one is not running is not retried: its error is
+// returned, so that Hedge composes with Do for retries. When both calls fail, the error of the
+// last one is returned.
+func Hedge(ctx context.Context, delay time.Duration, fn func(ctx context.Context) error, opts ...HedgeOption) error {
+	h := &hedge{clock: realClock{}}
+
+	for _, opt := range opts {
+		if err := opt(h); err != nil {
+			return fmt.Errorf("failed to apply option: %w", err)
+		}
+	}
+
+	return h.do(ctx, delay, fn)
+}
+
+func (h *hedge) do(ctx context.Context, delay time.Duration, fn func(ctx context.Context) error) error {
+	const calls = 2
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered so that the losing call does not block once Hedge has returned.
+	results := make(chan error, calls)
+
+	call := func() {
+		go func() {
+			results <- fn(ctx)
+		}()
+	}
+
+	call()
+
+	timer := h.clock.NewTimer(delay)
+	defer timer.Stop()
+
+	started, done := 1, 0
+
+	for {
+		select {
+		case <-timer.C():
+			if started < calls {
+				started++
+				call()
+			}
+		case err := <-results:
+			done++
+
+			if err == nil || done == started {
+				return err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+)
+
+func TestHedgeReturnsFirstFailureWithoutSecondCall(t *testing.T) {
+	var calls int32
+
+	err := retry.Hedge(context.Background(), time.Second, func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return retry.Permanent(errFail)
+	}, retry.HedgeWithClock(newClock()))
+
+	if !errors.Is(err, errFail) {
+		t.Fatalf("Hedge() error = %v, want %v", err, errFail)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("calls = %d, want 1", n)
+	}
+}
+
+func TestHedgeSecondCallWinsAfterDelay(t *testing.T) {
+	clock := newClock()
+
+	var calls int32
+
+	firstCanceled := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- retry.Hedge(context.Background(), time.Second, func(ctx context.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				// the first call hangs until the hedge wins.
+				<-ctx.Done()
+				close(firstCanceled)
+
+				return ctx.Err()
+			}
+
+			return nil
+		}, retry.HedgeWithClock(clock))
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Hedge() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Hedge() did not return")
+	}
+
+	select {
+	case <-firstCanceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first call was not cancelled")
+	}
+}
+
+func TestHedgeBothFail(t *testing.T) {
+	clock := newClock()
+	errSecond := errors.New("second")
+
+	var calls int32
+
+	release := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- retry.Hedge(context.Background(), time.Second, func(ctx context.Context) error {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-release
+				return errFail
+			}
+
+			close(release)
+
+			return errSecond
+		}, retry.HedgeWithClock(clock))
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errFail) && !errors.Is(err, errSecond) {
+			t.Fatalf("Hedge() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Hedge() did not return")
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("calls = %d, want 2", n)
+	}
+}
@@ -0,0 +1,20 @@
+package retry
+
+import "time"
+
+// Attempt describes a failed attempt, reported to hooks.
+type Attempt struct {
+	// Number is the attempt number, starting at 1.
+	Number int
+
+	// Elapsed is the time since Do was called.
+	Elapsed time.Duration
+
+	// NextDelay is the delay before the next attempt, or zero when Do gives up.
+	NextDelay time.Duration
+
+	Err error
+}
+
+// Hook is called after every failed attempt, e.g. to log or record metrics.
+type Hook func(Attempt)
@@ -3,6 +3,7 @@ package retry
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"strconv"
 	"time"
 )
@@ -16,11 +17,11 @@ var defaultOptions = []Option{
 	WithJitter(0.2),
 	WithBackoffTimeout("3m"),
 	WithRetryCnt(5),
-	WithErrorFunc(nopErrorFunc),
+	WithRetryIf(retryAll),
 }
 
 var (
-	nopErrorFunc     = func(error) {}
+	retryAll         = func(error) bool { return true }
 	errInvalidNumber = errors.New("invalid number")
 )
 
@@ -119,14 +120,98 @@ func WithJitter(f float64) Option {
 	}
 }
 
-// WithErrorFunc returns Option that sets the value to the retry.errorFunc.
-func WithErrorFunc(fn func(err error)) Option {
+// WithAttemptTimeout returns Option that sets the value to the retry.attemptTimeout.
+// Each call of the retried function gets a child context that expires after it.
+func WithAttemptTimeout(str string) Option {
+	return func(r *retry) error {
+		d, err := parseDuration(str)
+		if err != nil {
+			return fmt.Errorf("failed to set retry.attemptTimeout: %s: %w", str, err)
+		}
+
+		r.attemptTimeout = d
+
+		return nil
+	}
+}
+
+// WithHook returns Option that appends fn to the retry.hooks.
+func WithHook(fn Hook) Option {
 	return func(r *retry) error {
 		if fn == nil {
-			return errors.New("failed to set retry.errorFunc")
+			return errors.New("failed to set retry.hooks")
+		}
+
+		r.hooks = append(r.hooks, fn)
+
+		return nil
+	}
+}
+
+// WithBudget returns Option that sets the value to the retry.budget.
+// Retriers sharing a budget retry at most its ratio of their calls together.
+func WithBudget(b *Budget) Option {
+	return func(r *retry) error {
+		if b == nil {
+			return errors.New("failed to set retry.budget")
+		}
+
+		r.budget = b
+
+		return nil
+	}
+}
+
+// WithRetryIf returns Option that sets the value to the retry.retryIf.
+// Do returns errors for which fn reports false without retrying, e.g. IsRetryableMongo.
+func WithRetryIf(fn func(err error) bool) Option {
+	return func(r *retry) error {
+		if fn == nil {
+			return errors.New("failed to set retry.retryIf")
+		}
+
+		r.retryIf = fn
+
+		return nil
+	}
+}
+
+// WithClock returns Option that sets the value to the retry.clock.
+func WithClock(c Clock) Option {
+	return func(r *retry) error {
+		if c == nil {
+			return errors.New("failed to set retry.clock")
+		}
+
+		r.clock = c
+
+		return nil
+	}
+}
+
+// WithRandSource returns Option that sets the source of the backoff jitter,
+// e.g. rand.NewSource(1) for reproducible backoffs.
+func WithRandSource(src rand.Source) Option {
+	return func(r *retry) error {
+		if src == nil {
+			return errors.New("failed to set retry.rand")
+		}
+
+		r.rand = rand.New(src)
+
+		return nil
+	}
+}
+
+// WithCircuitBreaker returns Option that sets the value to the retry.breaker.
+// Do then fails fast with ErrCircuitOpen while the circuit is open.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(r *retry) error {
+		if b == nil {
+			return errors.New("failed to set retry.breaker")
 		}
 
-		r.errorFunc = fn
+		r.breaker = b
 
 		return nil
 	}
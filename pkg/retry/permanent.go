@@ -0,0 +1,34 @@
+package retry
+
+import (
+	"errors"
+)
+
+// PermanentError marks an error that must not be retried.
+type PermanentError struct {
+	Err error
+}
+
+// Permanent wraps err so that Do returns it without retrying. It returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is marked as permanent by Permanent.
+// Other errors are classified by the WithRetryIf classifier.
+func IsPermanent(err error) bool {
+	var p *PermanentError
+	return errors.As(err, &p)
+}
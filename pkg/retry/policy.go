@@ -0,0 +1,206 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// policy keys, e.g. "initial=50ms,factor=2,max=8,timeout=30s".
+const (
+	policyInitial        = "initial"
+	policyFactor         = "factor"
+	policyJitter         = "jitter"
+	policyMax            = "max"
+	policyTimeout        = "timeout"
+	policyAttemptTimeout = "attempt_timeout"
+)
+
+// Policy is a retry configuration that can be decoded from a config string.
+// Zero fields keep the defaults of New.
+type Policy struct {
+	InitialDelay   time.Duration
+	Factor         float64
+	Jitter         float64
+	MaxRetries     int
+	Timeout        time.Duration
+	AttemptTimeout time.Duration
+}
+
+// ParsePolicy parses comma separated key=value pairs, with keys initial, factor, jitter,
+// max, timeout and attempt_timeout.
+func ParsePolicy(str string) (Policy, error) {
+	var p Policy
+
+	for _, pair := range strings.Split(str, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return Policy{}, fmt.Errorf("invalid pair %q: expected key=value", pair)
+		}
+
+		if err := p.set(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])); err != nil {
+			return Policy{}, err
+		}
+	}
+
+	if _, err := New(p.Options()...); err != nil {
+		return Policy{}, err
+	}
+
+	return p, nil
+}
+
+func (p *Policy) set(key, val string) error {
+	var err error
+
+	switch key {
+	case policyInitial:
+		p.InitialDelay, err = parseDuration(val)
+	case policyTimeout:
+		p.Timeout, err = parseDuration(val)
+	case policyAttemptTimeout:
+		p.AttemptTimeout, err = parseDuration(val)
+	case policyMax:
+		p.MaxRetries, err = parseInt(val)
+	case policyFactor:
+		p.Factor, err = parsePositiveFloat(val)
+	case policyJitter:
+		p.Jitter, err = parsePositiveFloat(val)
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, val, err)
+	}
+
+	return nil
+}
+
+// parsePositiveFloat rejects zero, which would otherwise silently keep the default.
+func parsePositiveFloat(str string) (float64, error) {
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert: %w", err)
+	}
+
+	if f <= 0 {
+		return 0, errInvalidNumber
+	}
+
+	return f, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so that policies can be config values.
+func (p *Policy) UnmarshalText(text []byte) error {
+	parsed, err := ParsePolicy(string(text))
+	if err != nil {
+		return err
+	}
+
+	*p = parsed
+
+	return nil
+}
+
+// String formats p like ParsePolicy expects, omitting zero fields.
+func (p Policy) String() string {
+	pairs := []string{}
+
+	add := func(key string, set bool, val string) {
+		if set {
+			pairs = append(pairs, key+"="+val)
+		}
+	}
+
+	add(policyInitial, p.InitialDelay > 0, p.InitialDelay.String())
+	add(policyFactor, p.Factor != 0, strconv.FormatFloat(p.Factor, 'g', -1, 64))
+	add(policyJitter, p.Jitter != 0, strconv.FormatFloat(p.Jitter, 'g', -1, 64))
+	add(policyMax, p.MaxRetries > 0, strconv.Itoa(p.MaxRetries))
+	add(policyTimeout, p.Timeout > 0, p.Timeout.String())
+	add(policyAttemptTimeout, p.AttemptTimeout > 0, p.AttemptTimeout.String())
+
+	return strings.Join(pairs, ",")
+}
+
+// Options returns the options applying p.
+func (p Policy) Options() []Option {
+	opts := []Option{}
+
+	if p.InitialDelay != 0 {
+		opts = append(opts, WithInitialDelay(p.InitialDelay.String()))
+	}
+
+	if p.Factor != 0 {
+		opts = append(opts, WithBackoffFactor(p.Factor))
+	}
+
+	if p.Jitter != 0 {
+		opts = append(opts, WithJitter(p.Jitter))
+	}
+
+	if p.MaxRetries != 0 {
+		opts = append(opts, WithRetryCnt(p.MaxRetries))
+	}
+
+	if p.Timeout != 0 {
+		opts = append(opts, WithBackoffTimeout(p.Timeout.String()))
+	}
+
+	if p.AttemptTimeout != 0 {
+		opts = append(opts, WithAttemptTimeout(p.AttemptTimeout.String()))
+	}
+
+	return opts
+}
+
+// ErrUnknownPolicy is returned by NewNamed for a name without registered policy.
+var ErrUnknownPolicy = errors.New("unknown retry policy")
+
+// policies holds the named policies, registered from the configuration at startup.
+var policies = struct {
+	sync.RWMutex
+	m map[string]Policy
+}{m: map[string]Policy{}}
+
+// RegisterPolicy registers p under name, replacing any previous policy.
+func RegisterPolicy(name string, p Policy) {
+	policies.Lock()
+	defer policies.Unlock()
+
+	policies.m[name] = p
+}
+
+// LookupPolicy returns the policy registered under name.
+func LookupPolicy(name string) (Policy, bool) {
+	policies.RLock()
+	defer policies.RUnlock()
+
+	p, ok := policies.m[name]
+
+	return p, ok
+}
+
+// NewNamed returns a Retrier configured by the policy registered under name, then by opts.
+// An unknown name is an error, so that a misspelled name does not silently fall back to the defaults.
+func NewNamed(name string, opts ...Option) (Retrier, error) {
+	p, ok := LookupPolicy(name)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnknownPolicy)
+	}
+
+	r, err := New(append(p.Options(), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s retrier: %w", name, err)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,52 @@
+package retry_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+)
+
+func TestParsePolicy(t *testing.T) {
+	p, err := retry.ParsePolicy("initial=50ms, factor=2,max=8,timeout=30s,attempt_timeout=5s")
+	if err != nil {
+		t.Fatalf("ParsePolicy() error = %v", err)
+	}
+
+	want := retry.Policy{InitialDelay: 50 * time.Millisecond, Factor: 2, MaxRetries: 8, Timeout: 30 * time.Second, AttemptTimeout: 5 * time.Second}
+	if p != want {
+		t.Fatalf("ParsePolicy() = %+v, want %+v", p, want)
+	}
+
+	if got, err := retry.ParsePolicy(p.String()); err != nil || got != p {
+		t.Fatalf("ParsePolicy(%q) = %+v, %v, want %+v", p.String(), got, err, p)
+	}
+}
+
+func TestParsePolicyRejectsInvalid(t *testing.T) {
+	for _, str := range []string{
+		"initial",
+		"initial=-1s",
+		"max=0",
+		"factor=0",
+		"jitter=0",
+		"retries=3",
+	} {
+		if _, err := retry.ParsePolicy(str); err == nil {
+			t.Errorf("ParsePolicy(%q) succeeded, want an error", str)
+		}
+	}
+}
+
+func TestNewNamed(t *testing.T) {
+	retry.RegisterPolicy("test_named", retry.Policy{MaxRetries: 2})
+
+	if _, err := retry.NewNamed("test_named"); err != nil {
+		t.Fatalf("NewNamed() error = %v", err)
+	}
+
+	if _, err := retry.NewNamed("test_nmaed"); !errors.Is(err, retry.ErrUnknownPolicy) {
+		t.Fatalf("NewNamed() of an unknown name error = %v, want ErrUnknownPolicy", err)
+	}
+}
@@ -5,9 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const instrumentationName = "github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+
 // provide errors for retry package.
 var (
 	ErrBackOffTimeout  = errors.New("backoff timeout")
@@ -37,13 +45,35 @@ type retry struct {
 	// jitter is the factor with which backoffs are randomized.
 	jitter float64
 
-	// errorFunc is the function to report an error.
-	errorFunc func(error)
+	// attemptTimeout, if set, bounds every call of the retried function.
+	attemptTimeout time.Duration
+
+	// hooks are called after every failed attempt.
+	hooks []Hook
+
+	// budget, if set, is shared with other retriers and limits their retries.
+	budget *Budget
+
+	// retryIf reports whether an error is worth retrying. Permanent errors are never retried.
+	retryIf func(error) bool
+
+	// clock creates the backoff timers.
+	clock Clock
+
+	// rand randomizes backoffs; *rand.Rand is not safe for concurrent use.
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	// breaker, if set, is consulted before and told the result of each attempt.
+	breaker *CircuitBreaker
 }
 
 // New returns Retrier implementation and error.
 func New(opts ...Option) (Retrier, error) {
-	r := new(retry)
+	r := &retry{
+		clock: realClock{},
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
 
 	for _, opt := range append(defaultOptions, opts...) {
 		if err := opt(r); err != nil {
@@ -51,42 +81,138 @@ func New(opts ...Option) (Retrier, error) {
 		}
 	}
 
-	rand.Seed(time.Now().UnixNano())
-
 	return r, nil
 }
 
 func (r *retry) Do(ctx context.Context, fn func(ctx context.Context) error) error {
-	limit := time.NewTimer(r.backoffTimeout)
+	start := r.clock.Now()
+
+	limit := r.clock.NewTimer(r.backoffTimeout)
 	defer limit.Stop()
 
-	timer := time.NewTimer(time.Minute)
-	defer timer.Stop()
+	// timer waits the backoffs; it is created on the first failure.
+	var timer Timer
+
+	tracer := otel.Tracer(instrumentationName)
+
+	if r.budget != nil {
+		r.budget.deposit()
+	}
 
 	durf := float64(r.initialDuration)
 
+	// backoff is the delay waited before the current attempt.
+	var backoff time.Duration
+
 	var err error
-	for cnt := 0; cnt < r.maxRetryCnt; cnt++ {
-		if err = fn(ctx); err != nil {
-			r.errorFunc(err)
-
-			timer.Reset(time.Duration(durf))
-			select {
-			case <-limit.C:
-				return fmt.Errorf("%s: %w", err.Error(), ErrBackOffTimeout)
-			case <-ctx.Done():
-				return fmt.Errorf("%s: %w", err.Error(), ctx.Err())
-			case <-timer.C:
-				// NOTE: https://chromium.googlesource.com/external/github.com/grpc/grpc-go/+/refs/heads/v1.10.x/backoff.go
-				durf *= r.factor
-				durf *= 1 + r.jitter*(rand.Float64()*2-1)
-
-				continue
-			}
+	for cnt := 1; ; cnt++ {
+		if err = r.attempt(ctx, tracer, cnt, backoff, fn); err == nil {
+			return nil
+		}
+
+		if errors.Is(err, ErrCircuitOpen) {
+			return err
+		}
+
+		var stop error
+
+		switch {
+		case IsPermanent(err) || !r.retryIf(err):
+			stop = err
+		case cnt >= r.maxRetryCnt:
+			stop = fmt.Errorf("%s: %w", err.Error(), ErrReachedMaxRetry)
+		case r.budget != nil && !r.budget.withdraw():
+			stop = fmt.Errorf("%s: %w", err.Error(), ErrBudgetExhausted)
+		}
+
+		if stop != nil {
+			r.report(Attempt{Number: cnt, Elapsed: r.clock.Now().Sub(start), Err: err})
+
+			return stop
+		}
+
+		backoff = r.backoff(err, durf)
+		r.report(Attempt{Number: cnt, Elapsed: r.clock.Now().Sub(start), NextDelay: backoff, Err: err})
+
+		if timer == nil {
+			timer = r.clock.NewTimer(backoff)
+			defer timer.Stop()
+		} else {
+			timer.Reset(backoff)
+		}
+
+		select {
+		case <-limit.C():
+			return fmt.Errorf("%s: %w", err.Error(), ErrBackOffTimeout)
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", err.Error(), ctx.Err())
+		case <-timer.C():
+			durf = r.next(durf)
+		}
+	}
+}
+
+// backoff returns the delay before the attempt following the failure err.
+// A delay suggested by the server replaces the computed one, bounded by the backoff timeout.
+func (r *retry) backoff(err error, durf float64) time.Duration {
+	d, ok := suggestedDelay(err)
+	if !ok {
+		return time.Duration(durf)
+	}
+
+	if d > r.backoffTimeout {
+		return r.backoffTimeout
+	}
+
+	return d
+}
+
+func (r *retry) report(a Attempt) {
+	for _, h := range r.hooks {
+		h(a)
+	}
+}
+
+// next returns the backoff following durf.
+// NOTE: https://chromium.googlesource.com/external/github.com/grpc/grpc-go/+/refs/heads/v1.10.x/backoff.go
+func (r *retry) next(durf float64) float64 {
+	r.randMu.Lock()
+	f := r.rand.Float64()
+	r.randMu.Unlock()
+
+	return durf * r.factor * (1 + r.jitter*(f*2-1))
+}
+
+// attempt calls fn inside a span describing the attempt.
+func (r *retry) attempt(ctx context.Context, tracer trace.Tracer, n int, backoff time.Duration, fn func(ctx context.Context) error) error {
+	ctx, span := tracer.Start(ctx, "retry.attempt", trace.WithAttributes(
+		attribute.Int("retry.attempt", n),
+		attribute.Int64("retry.backoff_ms", backoff.Milliseconds()),
+	))
+	defer span.End()
+
+	call := fn
+	if r.attemptTimeout > 0 {
+		call = func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, r.attemptTimeout)
+			defer cancel()
+
+			return fn(ctx)
 		}
+	}
+
+	var err error
+	if r.breaker != nil {
+		// the breaker sees the caller's context, so that attempt timeouts count as failures.
+		err = r.breaker.Execute(ctx, call)
+	} else {
+		err = call(ctx)
+	}
 
-		return nil
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 	}
 
-	return fmt.Errorf("%s: %w", err.Error(), ErrReachedMaxRetry)
+	return err
 }
@@ -0,0 +1,362 @@
+package retry_test
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry/retrytest"
+)
+
+var errFail = errors.New("fail")
+
+// run calls Do with fn in a goroutine, advancing clock by every backoff reported to the hook.
+// It returns the failed attempts and the error of Do.
+func run(t *testing.T, clock *retrytest.Clock, fn func(ctx context.Context) error, opts ...retry.Option) ([]retry.Attempt, error) {
+	t.Helper()
+
+	attempts := make(chan retry.Attempt, 100)
+
+	r, err := retry.New(append([]retry.Option{
+		retry.WithClock(clock),
+		retry.WithRandSource(rand.NewSource(1)),
+		retry.WithHook(func(a retry.Attempt) { attempts <- a }),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.Do(context.Background(), fn)
+	}()
+
+	var got []retry.Attempt
+
+	for {
+		select {
+		case err := <-done:
+			return got, err
+		case a := <-attempts:
+			got = append(got, a)
+
+			if a.NextDelay > 0 {
+				// the backoff timeout timer and the backoff timer.
+				clock.BlockUntil(2)
+				clock.Advance(a.NextDelay)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Do() did not return")
+		}
+	}
+}
+
+func newClock() *retrytest.Clock {
+	return retrytest.NewClock(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+func failing(calls *int) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		return errFail
+	}
+}
+
+func TestDoReturnsOnSuccess(t *testing.T) {
+	calls := 0
+
+	attempts, err := run(t, newClock(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errFail
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if calls != 3 || len(attempts) != 2 {
+		t.Fatalf("calls = %d, failed attempts = %d, want 3 and 2", calls, len(attempts))
+	}
+}
+
+func TestDoBackoffGrowth(t *testing.T) {
+	const (
+		factor = 2.0
+		jitter = 0.1
+	)
+
+	calls := 0
+
+	attempts, err := run(t, newClock(), failing(&calls),
+		retry.WithInitialDelay("100ms"),
+		retry.WithBackoffFactor(factor),
+		retry.WithJitter(jitter),
+		retry.WithRetryCnt(6),
+		retry.WithBackoffTimeout("1h"),
+	)
+
+	if !errors.Is(err, retry.ErrReachedMaxRetry) {
+		t.Fatalf("Do() error = %v, want ErrReachedMaxRetry", err)
+	}
+
+	if len(attempts) != 6 {
+		t.Fatalf("failed attempts = %d, want 6", len(attempts))
+	}
+
+	if d := attempts[0].NextDelay; d != 100*time.Millisecond {
+		t.Fatalf("first backoff = %s, want the initial delay", d)
+	}
+
+	for i := 1; i < 5; i++ {
+		prev, next := attempts[i-1].NextDelay, attempts[i].NextDelay
+		ratio := float64(next) / float64(prev)
+
+		if ratio < factor*(1-jitter) || ratio > factor*(1+jitter) {
+			t.Errorf("backoff %d = %s after %s: ratio %.3f outside [%.2f, %.2f]", i+1, next, prev, ratio, factor*(1-jitter), factor*(1+jitter))
+		}
+	}
+
+	if d := attempts[5].NextDelay; d != 0 {
+		t.Errorf("last attempt NextDelay = %s, want 0", d)
+	}
+
+	for i, a := range attempts {
+		if a.Number != i+1 || !errors.Is(a.Err, errFail) {
+			t.Errorf("attempt %d = %+v", i+1, a)
+		}
+	}
+}
+
+func TestDoJitterBounds(t *testing.T) {
+	const jitter = 0.5
+
+	calls := 0
+
+	attempts, _ := run(t, newClock(), failing(&calls),
+		retry.WithBackoffFactor(1),
+		retry.WithJitter(jitter),
+		retry.WithRetryCnt(50),
+		retry.WithBackoffTimeout("1h"),
+	)
+
+	distinct := map[time.Duration]bool{}
+
+	for i := 1; i < len(attempts)-1; i++ {
+		prev, next := attempts[i-1].NextDelay, attempts[i].NextDelay
+		ratio := float64(next) / float64(prev)
+
+		if ratio < 1-jitter || ratio > 1+jitter {
+			t.Errorf("backoff %d = %s after %s: ratio %.3f outside [%.2f, %.2f]", i+1, next, prev, ratio, 1-jitter, 1+jitter)
+		}
+
+		distinct[next] = true
+	}
+
+	if len(distinct) < 10 {
+		t.Errorf("only %d distinct backoffs out of %d, jitter is not applied", len(distinct), len(attempts)-2)
+	}
+}
+
+func TestDoRandSourceIsDeterministic(t *testing.T) {
+	delays := func() []time.Duration {
+		calls := 0
+		attempts, _ := run(t, newClock(), failing(&calls), retry.WithRetryCnt(8), retry.WithBackoffTimeout("1h"))
+
+		ds := make([]time.Duration, 0, len(attempts))
+		for _, a := range attempts {
+			ds = append(ds, a.NextDelay)
+		}
+
+		return ds
+	}
+
+	a, b := delays(), delays()
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("backoffs differ with the same source: %v and %v", a, b)
+		}
+	}
+}
+
+func TestDoMaxRetryCnt(t *testing.T) {
+	calls := 0
+
+	_, err := run(t, newClock(), failing(&calls), retry.WithRetryCnt(3))
+
+	if !errors.Is(err, retry.ErrReachedMaxRetry) {
+		t.Fatalf("Do() error = %v, want ErrReachedMaxRetry", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3", calls)
+	}
+}
+
+func TestDoBackoffTimeout(t *testing.T) {
+	clock := newClock()
+	calls := 0
+
+	r, err := retry.New(
+		retry.WithClock(clock),
+		retry.WithInitialDelay("1s"),
+		retry.WithBackoffTimeout("1500ms"),
+		retry.WithRetryCnt(10),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.Do(context.Background(), failing(&calls))
+	}()
+
+	// first backoff of 1s, then a longer one outlasting the timeout.
+	clock.BlockUntil(2)
+	clock.Advance(time.Second)
+	clock.BlockUntil(2)
+	clock.Advance(500 * time.Millisecond)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, retry.ErrBackOffTimeout) {
+			t.Fatalf("Do() error = %v, want ErrBackOffTimeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Do() did not time out")
+	}
+
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
+
+func TestDoServerDelayIsCappedByTimeout(t *testing.T) {
+	calls := 0
+
+	attempts, _ := run(t, newClock(), func(context.Context) error {
+		calls++
+		return retry.WithDelay(errFail, time.Hour)
+	}, retry.WithRetryCnt(2), retry.WithBackoffTimeout("1m"))
+
+	if d := attempts[0].NextDelay; d != time.Minute {
+		t.Fatalf("backoff = %s, want the suggested delay capped at the 1m timeout", d)
+	}
+}
+
+func TestDoServerDelay(t *testing.T) {
+	calls := 0
+
+	attempts, _ := run(t, newClock(), func(context.Context) error {
+		calls++
+		return retry.WithDelay(errFail, 7*time.Second)
+	}, retry.WithRetryCnt(2))
+
+	if d := attempts[0].NextDelay; d != 7*time.Second {
+		t.Fatalf("backoff = %s, want the suggested 7s", d)
+	}
+}
+
+func TestDoStopsOnCancel(t *testing.T) {
+	clock := newClock()
+	calls := 0
+
+	r, err := retry.New(retry.WithClock(clock))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- r.Do(ctx, failing(&calls))
+	}()
+
+	clock.BlockUntil(2)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Do() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Do() ignored the cancellation")
+	}
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoStopsOnDeadline(t *testing.T) {
+	calls := 0
+
+	r, err := retry.New(retry.WithClock(newClock()))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// the fake clock never advances, so only the context can end the backoff.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = r.Do(ctx, failing(&calls))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Do() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoDoesNotRetryPermanentErrors(t *testing.T) {
+	calls := 0
+
+	_, err := run(t, newClock(), func(context.Context) error {
+		calls++
+		return retry.Permanent(errFail)
+	})
+
+	if !errors.Is(err, errFail) || !retry.IsPermanent(err) {
+		t.Fatalf("Do() error = %v, want the permanent error", err)
+	}
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoRetryIf(t *testing.T) {
+	errOther := errors.New("other")
+	calls := 0
+
+	_, err := run(t, newClock(), func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return errFail
+		}
+
+		return errOther
+	}, retry.WithRetryIf(func(err error) bool { return errors.Is(err, errFail) }))
+
+	if !errors.Is(err, errOther) {
+		t.Fatalf("Do() error = %v, want %v", err, errOther)
+	}
+
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,135 @@
+// Package retrytest provides helpers to test code using pkg/retry deterministically.
+package retrytest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/puipuipartpicker/kbpartpicker/api/pkg/retry"
+)
+
+// Clock is a retry.Clock whose time only moves when Advance is called.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+
+	// waiters are notified whenever a timer is created or reset.
+	waiters []chan struct{}
+}
+
+// NewClock returns a clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements retry.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer implements retry.Clock.
+func (c *Clock) NewTimer(d time.Duration) retry.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &timer{clock: c, c: make(chan time.Time, 1), deadline: c.now.Add(d), active: true}
+	c.timers = append(c.timers, t)
+	c.notify()
+
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers whose deadline has passed.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+
+			select {
+			case t.c <- c.now:
+			default:
+			}
+		}
+	}
+}
+
+// BlockUntil waits until n timers are active, e.g. until the code under test waits for a backoff.
+func (c *Clock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		if c.active() >= n {
+			c.mu.Unlock()
+			return
+		}
+
+		ch := make(chan struct{})
+		c.waiters = append(c.waiters, ch)
+		c.mu.Unlock()
+
+		<-ch
+	}
+}
+
+// active must be called with c.mu held.
+func (c *Clock) active() int {
+	n := 0
+
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+
+	return n
+}
+
+// notify must be called with c.mu held.
+func (c *Clock) notify() {
+	for _, ch := range c.waiters {
+		close(ch)
+	}
+
+	c.waiters = nil
+}
+
+type timer struct {
+	clock    *Clock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = false
+
+	return wasActive
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	wasActive := t.active
+	t.active = true
+	t.deadline = t.clock.now.Add(d)
+	t.clock.notify()
+
+	return wasActive
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/puipuipartpicker/kbpartpicker/api/pkg/tracing"
+
+// headerCarrier adapts fiber request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key string, value string) {
+	h.c.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := []string{}
+	h.c.Request().Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+
+	return keys
+}
+
+// Middleware is a fiber middleware starting a server span for each request.
+// The incoming traceparent header is honored and the span is stored in the user context.
+func Middleware(c *fiber.Ctx) error {
+	tracer := otel.Tracer(instrumentationName)
+
+	ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c: c})
+	ctx, span := tracer.Start(ctx, c.Method()+" "+c.Path(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(c.Method()),
+			semconv.HTTPTargetKey.String(c.OriginalURL()),
+			semconv.HTTPUserAgentKey.String(c.Get(fiber.HeaderUserAgent)),
+		),
+	)
+	defer span.End()
+
+	c.SetUserContext(ctx)
+
+	err := c.Next()
+
+	route := c.Route().Path
+	span.SetName(c.Method() + " " + route)
+	span.SetAttributes(semconv.HTTPRouteKey.String(route))
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+	span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(status))
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporter is the name of a span exporter.
+type Exporter string
+
+const (
+	// ExporterNone disables span export; spans are still created so trace IDs propagate.
+	ExporterNone Exporter = "none"
+	// ExporterStdout writes spans to stdout.
+	ExporterStdout Exporter = "stdout"
+	// ExporterOTLP sends spans to an OTLP/HTTP collector.
+	ExporterOTLP Exporter = "otlp"
+)
+
+var errUnsupportedExporter = errors.New("unsupported exporter")
+
+// Config configures the tracer provider.
+type Config struct {
+	ServiceName string
+	Exporter    Exporter
+
+	// Endpoint is the host:port of the OTLP collector, e.g. localhost:4318.
+	Endpoint string
+
+	// Insecure disables TLS for the OTLP collector connection.
+	Insecure bool
+}
+
+type shutdownCloser struct {
+	provider *sdktrace.TracerProvider
+}
+
+func (s *shutdownCloser) Close() error {
+	// a provider without span processors fails to shut down, and has nothing to flush.
+	if s.provider == nil {
+		return nil
+	}
+
+	return s.provider.Shutdown(context.Background())
+}
+
+// Init installs the global tracer provider and the W3C trace context propagator.
+// The returned closer flushes pending spans.
+func Init(ctx context.Context, cfg Config) (io.Closer, error) {
+	opts := []sdktrace.TracerProviderOption{}
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init stdout exporter: %w", err)
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		clientOpts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+
+		exp, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init otlp exporter: %w", err)
+		}
+
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("%s: %w", cfg.Exporter, errUnsupportedExporter)
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceNameKey.String(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(append(opts, sdktrace.WithResource(res))...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if len(opts) == 0 {
+		return &shutdownCloser{}, nil
+	}
+
+	return &shutdownCloser{provider: provider}, nil
+}
+
+// Inject writes the traceparent of ctx into the headers of an outgoing request.
+func Inject(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// IDs returns the trace and span IDs of the span in ctx, or empty strings if there is none.
+func IDs(ctx context.Context) (traceID string, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+
+	return sc.TraceID().String(), sc.SpanID().String()
+}